@@ -0,0 +1,173 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+// Command h3dpack builds, lists and extracts H3DP content archives.
+//
+// Usage:
+//
+//	h3dpack build [-store] archive.h3dp contentDir
+//	h3dpack list archive.h3dp
+//	h3dpack extract [-C dir] archive.h3dp [name ...]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"bitbucket.org/tshannon/gohorde/horde3d/pack"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "build":
+		err = build(args)
+	case "list":
+		err = list(args)
+	case "extract":
+		err = extract(args)
+	default:
+		usage()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "h3dpack:", err)
+		os.Exit(1)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `usage:
+	h3dpack build [-store] archive.h3dp contentDir
+	h3dpack list archive.h3dp
+	h3dpack extract [-C dir] archive.h3dp [name ...]`)
+	os.Exit(2)
+}
+
+func build(args []string) error {
+	flags := flag.NewFlagSet("build", flag.ExitOnError)
+	store := flags.Bool("store", false, "store entries without compression")
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		usage()
+	}
+
+	method := pack.Deflate
+	if *store {
+		method = pack.Store
+	}
+
+	f, err := os.Create(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w, err := pack.NewWriter(f)
+	if err != nil {
+		return err
+	}
+	if err := w.AddFS(os.DirFS(flags.Arg(1)), method); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func list(args []string) error {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		usage()
+	}
+
+	r, err := pack.OpenReader(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "size\tstored\tmethod\tmodified\t name")
+	for _, e := range r.Entries() {
+		fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t %s\n", e.OrigSize, e.Size, e.Method,
+			e.ModTime.Format("2006-01-02 15:04"), e.Name)
+	}
+	return tw.Flush()
+}
+
+func extract(args []string) error {
+	flags := flag.NewFlagSet("extract", flag.ExitOnError)
+	dir := flags.String("C", ".", "directory to extract to")
+	flags.Parse(args)
+	if flags.NArg() < 1 {
+		usage()
+	}
+
+	r, err := pack.OpenReader(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	names := flags.Args()[1:]
+	if len(names) == 0 {
+		for _, e := range r.Entries() {
+			names = append(names, e.Name)
+		}
+	}
+	for _, name := range names {
+		if err := extractFile(r, name, *dir); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func extractFile(fsys fs.FS, name string, dir string) error {
+	info, err := fs.Stat(fsys, name)
+	if err != nil {
+		return err
+	}
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	dest := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(dest, data, 0644); err != nil {
+		return err
+	}
+	if !info.ModTime().IsZero() {
+		return os.Chtimes(dest, info.ModTime(), info.ModTime())
+	}
+	return nil
+}
@@ -33,8 +33,8 @@ const (
 	appHeight = 600
 )
 
-var pipeRes horde3d.H3DRes
-var cam horde3d.H3DNode
+var pipeRes horde3d.Pipeline
+var cam horde3d.CameraNode
 
 func main() {
 	var running bool = true
@@ -65,7 +65,7 @@ func main() {
 	//horde3d.SetOption(horde3d.Options_DebugViewMode, 1)
 	// Add resources
 	//pipeline
-	pipeRes = horde3d.AddResource(horde3d.ResTypes_Pipeline, "pipelines/hdr.pipeline.xml", 0)
+	pipeRes = horde3d.AddPipeline("pipelines/hdr.pipeline.xml", 0)
 
 	knightRes := horde3d.AddResource(horde3d.ResTypes_SceneGraph, "models/knight/knight.scene.xml", 0)
 
@@ -76,9 +76,9 @@ func main() {
 	model.SetTransform(0, 0, -30, 0, 0, 0, 0.1, 0.1, 0.1)
 
 	// Add light source
-	light := horde3d.RootNode.AddLightNode("Light1", 0, "LIGHTING", "SHADOWMAP")
+	light := horde3d.RootNode.AddLightNode("Light1", horde3d.Material{}, "LIGHTING", "SHADOWMAP")
 	light.SetTransform(0, 20, 0, 0, 0, 0, 1, 1, 1)
-	light.SetNodeParamF(horde3d.Light_RadiusF, 0, 50)
+	light.SetRadius(50)
 
 	//add camera
 	cam = horde3d.RootNode.AddCameraNode("Camera", pipeRes)
@@ -86,7 +86,7 @@ func main() {
 
 	for running {
 
-		horde3d.Render(cam)
+		horde3d.Render(cam.H3DNode)
 		horde3d.FinalizeFrame()
 		horde3d.DumpMessages()
 		glfw.SwapBuffers()
@@ -102,12 +102,9 @@ func onResize(w, h int) {
 		h = 1
 	}
 
-	cam.SetNodeParamI(horde3d.Camera_ViewportXI, 0)
-	cam.SetNodeParamI(horde3d.Camera_ViewportYI, 0)
-	cam.SetNodeParamI(horde3d.Camera_ViewportWidthI, w)
-	cam.SetNodeParamI(horde3d.Camera_ViewportHeightI, h)
+	cam.SetViewport(0, 0, w, h)
 
-	horde3d.SetupCameraView(cam, 45.0, float32(w)/float32(h), 0.1, 1000.0)
-	horde3d.ResizePipelineBuffers(pipeRes, w, h)
+	cam.SetupView(45.0, float32(w)/float32(h), 0.1, 1000.0)
+	pipeRes.ResizeBuffers(w, h)
 
 }
@@ -22,9 +22,10 @@ package main
 
 import (
 	"bitbucket.org/tshannon/gohorde/horde3d"
+	hmath "bitbucket.org/tshannon/gohorde/horde3d/math"
 	"fmt"
 	"github.com/jteeuwen/glfw"
-	"math"
+	"os"
 )
 
 const (
@@ -198,21 +199,18 @@ func main() {
 	return
 }
 
-// Convert from degrees to radians
-func degToRad(f float32) float64 {
-	return float64(f) * (3.1415926 / 180.0)
-}
-
 type Application struct {
-	keys                          []bool
-	prevKeys                      []bool
-	x, y, z, rx, ry, rz, velocity float32
-	contentDir                    string
-	hdrPipeRes, forwardPipeRes, fontMatRes,
-	panelMatRes, logoMatRes horde3d.H3DRes
-	cam, knight, particleSys horde3d.H3DNode
-	animTime, weight, curFps float32
-	title                    string
+	keys                                []bool
+	prevKeys                            []bool
+	x, y, z, rx, ry, rz, velocity       float32
+	contentDir                          string
+	hdrPipeRes, forwardPipeRes          horde3d.Pipeline
+	fontMatRes, panelMatRes, logoMatRes horde3d.H3DRes
+	cam                                 horde3d.CameraNode
+	knight, particleSys                 horde3d.H3DNode
+	animTime, weight, curFps            float32
+	title                               string
+	engine                              *horde3d.Engine
 }
 
 func (app *Application) init() bool {
@@ -231,26 +229,28 @@ func (app *Application) init() bool {
 
 	app.animTime = 0
 	app.weight = 1.0
-	app.cam = 0
+	app.cam = horde3d.CameraNode{}
 
 	// Initialize engine
-	if !horde3d.Init() {
-		horde3d.DumpMessages()
+	cfg := horde3d.DefaultConfig()
+	cfg.LoadTextures = true
+	cfg.TexCompression = false
+	cfg.FastAnimation = false
+	cfg.MaxAnisotropy = 4
+	cfg.ShadowMapSize = 2048
+
+	engine, err := horde3d.NewEngine(cfg)
+	if err != nil {
+		fmt.Println(err)
 		return false
 	}
-
-	// Set options
-	horde3d.SetOption(horde3d.Options_LoadTextures, 1)
-	horde3d.SetOption(horde3d.Options_TexCompression, 0)
-	horde3d.SetOption(horde3d.Options_FastAnimation, 0)
-	horde3d.SetOption(horde3d.Options_MaxAnisotropy, 4)
-	horde3d.SetOption(horde3d.Options_ShadowMapSize, 2048)
+	app.engine = engine
 	//horde3d.SetOption(horde3d.Options_DebugViewMode, 1)
 
 	// Add resources
 	// Pipelines
-	app.hdrPipeRes = horde3d.AddResource(horde3d.ResTypes_Pipeline, "pipelines/hdr.pipeline.xml", 0)
-	app.forwardPipeRes = horde3d.AddResource(horde3d.ResTypes_Pipeline, "pipelines/forward.pipeline.xml", 0)
+	app.hdrPipeRes = horde3d.AddPipeline("pipelines/hdr.pipeline.xml", 0)
+	app.forwardPipeRes = horde3d.AddPipeline("pipelines/forward.pipeline.xml", 0)
 	// Overlays
 	app.fontMatRes = horde3d.AddResource(horde3d.ResTypes_Material, "overlays/font.material.xml", 0)
 	app.panelMatRes = horde3d.AddResource(horde3d.ResTypes_Material, "overlays/panel.material.xml", 0)
@@ -265,12 +265,14 @@ func (app *Application) init() bool {
 	particleSysRes := horde3d.AddResource(horde3d.ResTypes_SceneGraph,
 		"particles/particleSys1/particleSys1.scene.xml", 0)
 	// Load resources
-	horde3d.LoadResourcesFromDisk(app.contentDir)
+	if err := horde3d.LoadResourcesFromFS(os.DirFS(app.contentDir)); err != nil {
+		fmt.Println(err)
+	}
 
 	// Add scene nodes
 	// Add camera
 	app.cam = horde3d.RootNode.AddCameraNode("Camera", app.hdrPipeRes)
-	app.cam.SetNodeParamI(horde3d.Camera_OccCullingI, 0)
+	app.cam.SetOcclusionCulling(false)
 	// Add environment
 	env := horde3d.RootNode.AddNodes(envRes)
 	env.SetTransform(0, -20, 0, 0, 0, 0, 20, 20, 20)
@@ -280,22 +282,21 @@ func (app *Application) init() bool {
 	horde3d.SetupModelAnimStage(app.knight, 0, knightAnim1Res, 0, "", false)
 	horde3d.SetupModelAnimStage(app.knight, 1, knightAnim2Res, 0, "", false)
 	// Attach particle system to hand joint
-	horde3d.FindNodes(app.knight, "Bip01_R_Hand", horde3d.NodeTypes_Joint)
-	hand := horde3d.GetNodeFindResult(0)
+	hand := horde3d.H3DNode(0)
+	if joints := horde3d.FindNodes(app.knight, "Bip01_R_Hand", horde3d.NodeTypes_Joint); len(joints) > 0 {
+		hand = joints[0]
+	}
 	app.particleSys = hand.AddNodes(particleSysRes)
 	app.particleSys.SetTransform(0, 40, 0, 90, 0, 0, 1, 1, 1)
 
 	// Add light source
-	light := horde3d.RootNode.AddLightNode("Light1", 0, "LIGHTING", "SHADOWMAP")
+	light := horde3d.RootNode.AddLightNode("Light1", horde3d.Material{}, "LIGHTING", "SHADOWMAP")
 	light.SetTransform(0, 15, 10, -60, 0, 0, 1, 1, 1)
-	light.SetNodeParamF(horde3d.Light_RadiusF, 0, 30)
-	light.SetNodeParamF(horde3d.Light_FovF, 0, 90)
-	light.SetNodeParamI(horde3d.Light_ShadowMapCountI, 1)
-	light.SetNodeParamF(horde3d.Light_ShadowMapBiasF, 0, 0.01)
-	light.SetNodeParamF(horde3d.Light_ColorF3, 0, 1.0)
-	light.SetNodeParamF(horde3d.Light_ColorF3, 1, 0.8)
-	light.SetNodeParamF(horde3d.Light_ColorF3, 2, 0.7)
-	light.SetNodeParamF(horde3d.Light_ColorMultiplierF, 0, 1.0)
+	light.SetRadius(30)
+	light.SetFov(90)
+	light.SetShadowMaps(1, 0.01, 0.5)
+	light.SetColor(1.0, 0.8, 0.7)
+	light.SetColorMultiplier(1.0)
 
 	// Customize post processing effects
 	matRes := horde3d.FindResource(horde3d.ResTypes_Material, "pipelines/postHDR.material.xml")
@@ -316,9 +317,8 @@ func (app *Application) mainLoop(fps float32) {
 	horde3d.SetModelAnimParams(app.knight, 1, app.animTime*24.0, 1.0-app.weight)
 
 	// Animate particle systems (several emitters in a group node)
-	cnt := horde3d.FindNodes(app.particleSys, "", horde3d.NodeTypes_Emitter)
-	for i := 0; i < cnt; i++ {
-		horde3d.UpdateEmitter(horde3d.GetNodeFindResult(i), 1.0/app.curFps)
+	for _, emitter := range horde3d.FindNodes(app.particleSys, "", horde3d.NodeTypes_Emitter) {
+		horde3d.UpdateEmitter(emitter, 1.0/app.curFps)
 	}
 
 	// Set camera parameters
@@ -326,12 +326,12 @@ func (app *Application) mainLoop(fps float32) {
 
 	// Show stats
 	// Show logo
-	ww := float32(app.cam.NodeParamI(horde3d.Camera_ViewportWidthI)) /
-		float32(app.cam.NodeParamI(horde3d.Camera_ViewportHeightI))
+	_, _, vpWidth, vpHeight := app.cam.Viewport()
+	ww := float32(vpWidth) / float32(vpHeight)
 	ovLogo := []float32{ww - 0.4, 0.8, 0, 1, ww - 0.4, 1, 0, 0, ww, 1, 1, 0, ww, 0.8, 1, 1}
 	horde3d.ShowOverlays(ovLogo, 4, 1.0, 1.0, 1.0, 1.0, app.logoMatRes, 0)
 	// Render scene
-	horde3d.Render(app.cam)
+	horde3d.Render(app.cam.H3DNode)
 
 	// Finish rendering of frame
 	horde3d.FinalizeFrame()
@@ -344,21 +344,20 @@ func (app *Application) mainLoop(fps float32) {
 }
 
 func (app *Application) release() {
-	// Release engine
-	horde3d.Release()
+	// Release engine, init may have failed before it was created
+	if app.engine != nil {
+		app.engine.Close()
+	}
 }
 
 func (app *Application) resize(width int, height int) {
 	// Resize viewport
-	app.cam.SetNodeParamI(horde3d.Camera_ViewportXI, 0)
-	app.cam.SetNodeParamI(horde3d.Camera_ViewportYI, 0)
-	app.cam.SetNodeParamI(horde3d.Camera_ViewportWidthI, width)
-	app.cam.SetNodeParamI(horde3d.Camera_ViewportHeightI, height)
+	app.cam.SetViewport(0, 0, width, height)
 
 	// Set virtual camera parameters
-	horde3d.SetupCameraView(app.cam, 45.0, float32(width)/float32(height), 0.1, 1000.0)
-	horde3d.ResizePipelineBuffers(app.hdrPipeRes, width, height)
-	horde3d.ResizePipelineBuffers(app.forwardPipeRes, width, height)
+	app.cam.SetupView(45.0, float32(width)/float32(height), 0.1, 1000.0)
+	app.hdrPipeRes.ResizeBuffers(width, height)
+	app.forwardPipeRes.ResizeBuffers(width, height)
 }
 
 func (app *Application) keyStateHandler() {
@@ -367,10 +366,10 @@ func (app *Application) keyStateHandler() {
 	// ----------------
 
 	if app.keys[260] && !app.prevKeys[260] { // F3
-		if horde3d.H3DRes(app.cam.NodeParamI(horde3d.Camera_PipeResI)) == app.hdrPipeRes {
-			app.cam.SetNodeParamI(horde3d.Camera_PipeResI, int(app.forwardPipeRes))
+		if app.cam.Pipeline() == app.hdrPipeRes {
+			app.cam.SetPipeline(app.forwardPipeRes)
 		} else {
-			app.cam.SetNodeParamI(horde3d.Camera_PipeResI, int(app.hdrPipeRes))
+			app.cam.SetPipeline(app.hdrPipeRes)
 		}
 	}
 
@@ -378,34 +377,34 @@ func (app *Application) keyStateHandler() {
 	// Key-down state
 	// --------------
 
-	curVel := float64(app.velocity / app.curFps)
+	curVel := app.velocity / app.curFps
 
 	if app.keys[287] {
 		curVel *= 5 // LShift
 	}
 
+	pos := hmath.Vec3{X: app.x, Y: app.y, Z: app.z}
+	rot := hmath.QuatFromEuler(hmath.Vec3{X: app.rx, Y: app.ry})
+	forward := rot.Rotate(hmath.Vec3{Z: -1}).Mul(curVel)
+	right := hmath.QuatFromEuler(hmath.Vec3{Y: app.ry}).Rotate(hmath.Vec3{X: 1}).Mul(curVel)
+
 	if app.keys['W'] {
 		// Move forward
-		app.x -= float32(math.Sin(degToRad(app.ry)) * math.Cos(-degToRad(app.rx)) * curVel)
-		app.y -= float32(math.Sin(-degToRad(app.rx)) * float64(curVel))
-		app.z -= float32(math.Cos(degToRad(app.ry)) * math.Cos(-degToRad(app.rx)) * curVel)
+		pos = pos.Add(forward)
 	}
 	if app.keys['S'] {
 		// Move backward
-		app.x += float32(math.Sin(degToRad(app.ry)) * math.Cos(-degToRad(app.rx)) * curVel)
-		app.y += float32(math.Sin(-degToRad(app.rx)) * float64(curVel))
-		app.z += float32(math.Cos(degToRad(app.ry)) * math.Cos(-degToRad(app.rx)) * curVel)
+		pos = pos.Sub(forward)
 	}
 	if app.keys['A'] {
 		// Strafe left
-		app.x += float32(math.Sin(degToRad(app.ry-90)) * curVel)
-		app.z += float32(math.Cos(degToRad(app.ry-90)) * curVel)
+		pos = pos.Sub(right)
 	}
 	if app.keys['D'] {
 		// Strafe right
-		app.x += float32(math.Sin(degToRad(app.ry+90)) * curVel)
-		app.z += float32(math.Cos(degToRad(app.ry+90)) * curVel)
+		pos = pos.Add(right)
 	}
+	app.x, app.y, app.z = pos.X, pos.Y, pos.Z
 	if app.keys['1'] {
 		// Change blend weight
 		app.weight += 2 / app.curFps
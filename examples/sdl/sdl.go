@@ -58,7 +58,7 @@ func main() {
 	fmt.Println("Version: ", horde3d.VersionString())
 
 	//pipeline
-	pipeRes := horde3d.AddResource(horde3d.ResTypes_Pipeline, "forward.pipeline.xml", 0)
+	pipeRes := horde3d.AddPipeline("forward.pipeline.xml", 0)
 	modelRes := horde3d.AddResource(horde3d.ResTypes_SceneGraph, "platform.scene.xml", 0)
 
 	horde3d.LoadResourcesFromDisk("../content|" +
@@ -75,25 +75,19 @@ func main() {
 	//add camera
 	cam := horde3d.RootNode.AddCameraNode("Camera", pipeRes)
 	//Setup Camera Viewport
-	cam.SetNodeParamI(horde3d.Camera_ViewportXI, 0)
-	cam.SetNodeParamI(horde3d.Camera_ViewportYI, 0)
-	cam.SetNodeParamI(horde3d.Camera_ViewportWidthI, width)
-	cam.SetNodeParamI(horde3d.Camera_ViewportHeightI, height)
+	cam.SetViewport(0, 0, width, height)
 
 	//add model
 	model := horde3d.RootNode.AddNodes(modelRes)
 	model.SetTransform(0, -30, -150, 0, 0, 0, 1, 1, 1)
 	//add light
-	light := horde3d.RootNode.AddLightNode("Light1", 0, "LIGHTING", "SHADOWMAP")
+	light := horde3d.RootNode.AddLightNode("Light1", horde3d.Material{}, "LIGHTING", "SHADOWMAP")
 	light.SetTransform(0, 20, 0, 0, 0, 0, 1, 1, 1)
-	light.SetNodeParamF(horde3d.Light_RadiusF, 0, 150)
-	light.SetNodeParamF(horde3d.Light_FovF, 0, 90)
-	//horde3d.SetNodeParamI(light, horde3d.Light_ShadowMapCountI, 3)
+	light.SetRadius(150)
+	light.SetFov(90)
+	//light.SetShadowMaps(3, 0.001, 0.9)
 	light.SetNodeParamF(horde3d.Light_ShadowSplitLambdaF, 0, 0.9)
-	//horde3d.SetNodeParamF(light, horde3d.Light_ShadowMapBiasF, 0, 0.001)
-	light.SetNodeParamF(horde3d.Light_ColorF3, 0, 1.9)
-	light.SetNodeParamF(horde3d.Light_ColorF3, 1, 1.7)
-	light.SetNodeParamF(horde3d.Light_ColorF3, 2, 1.75)
+	light.SetColor(1.9, 1.7, 1.75)
 
 	for running {
 		t = 0
@@ -109,7 +103,7 @@ func main() {
 		//t*10, 0, 0,
 		//0, 0, 0,
 		//1, 1, 1)
-		horde3d.Render(cam)
+		horde3d.Render(cam.H3DNode)
 		horde3d.FinalizeFrame()
 		horde3d.DumpMessages()
 		sdl.GL_SwapBuffers()
@@ -0,0 +1,191 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+)
+
+var ErrDispatcherStopped = errors.New("horde3d: dispatcher stopped")
+
+// Dispatcher runs engine calls queued from any goroutine on the one OS thread that owns the
+// OpenGL context.  Calls run in the order they were queued.  A dispatched function must not
+// queue a synchronous call on the same Dispatcher, it would wait on itself.
+type Dispatcher struct {
+	queue    chan func()
+	stop     chan struct{}
+	finished chan struct{}
+
+	mu      sync.Mutex
+	stopped bool
+	started bool
+	senders sync.WaitGroup // Go calls that may still queue a function
+}
+
+func NewDispatcher(queueSize int) *Dispatcher {
+	return &Dispatcher{
+		queue:    make(chan func(), queueSize),
+		stop:     make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+}
+
+// Run locks the calling goroutine to its OS thread and runs queued calls until Stop is called.
+// Call it from the main goroutine when the windowing library has to run on the main thread,
+// otherwise use Start.  The OpenGL context should be created through the dispatcher as well.
+func (d *Dispatcher) Run() {
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	defer close(d.finished)
+
+	d.mu.Lock()
+	d.started = true
+	d.mu.Unlock()
+
+	for {
+		select {
+		case f := <-d.queue:
+			f()
+		case <-d.stop:
+			// finish whatever was queued before Stop, including by Go calls still in progress
+			d.senders.Wait()
+			for {
+				select {
+				case f := <-d.queue:
+					f()
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Start runs the dispatcher on a new goroutine
+func (d *Dispatcher) Start() {
+	d.mu.Lock()
+	d.started = true
+	d.mu.Unlock()
+	go d.Run()
+}
+
+// Stop makes Run return once the calls that are already queued have run, later calls are
+// rejected with ErrDispatcherStopped.  It doesn't wait for Run to return, so it can be called
+// from a dispatched function.  Call Wait afterwards to block until Run has returned.
+func (d *Dispatcher) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !d.stopped {
+		d.stopped = true
+		close(d.stop)
+	}
+}
+
+// Wait blocks until Run has returned after Stop.  It returns right away if the dispatcher was
+// never started, and must not be called from a dispatched function.
+func (d *Dispatcher) Wait() {
+	d.mu.Lock()
+	started := d.started
+	d.mu.Unlock()
+	if started {
+		<-d.finished
+	}
+}
+
+// Go queues f without waiting for it to run
+func (d *Dispatcher) Go(f func()) error {
+	d.mu.Lock()
+	if d.stopped {
+		d.mu.Unlock()
+		return ErrDispatcherStopped
+	}
+	d.senders.Add(1)
+	d.mu.Unlock()
+	defer d.senders.Done()
+
+	select {
+	case d.queue <- f:
+		return nil
+	case <-d.stop:
+		return ErrDispatcherStopped
+	}
+}
+
+// Call queues f and waits until it has run.  A panic in f is passed on to the caller.
+func (d *Dispatcher) Call(f func()) error {
+	done := make(chan interface{}, 1)
+	err := d.Go(func() {
+		defer func() {
+			done <- recover()
+		}()
+		f()
+	})
+	if err != nil {
+		return err
+	}
+
+	select {
+	case p := <-done:
+		if p != nil {
+			panic(p)
+		}
+		return nil
+	case <-d.finished:
+		// f may have been queued after Run returned
+		select {
+		case p := <-done:
+			if p != nil {
+				panic(p)
+			}
+			return nil
+		default:
+			return ErrDispatcherStopped
+		}
+	}
+}
+
+// CallValue queues f on the dispatcher and waits for its result
+func CallValue[T any](d *Dispatcher, f func() T) (T, error) {
+	var result T
+	err := d.Call(func() {
+		result = f()
+	})
+	return result, err
+}
+
+// Flush waits until every call queued before it has run
+func (d *Dispatcher) Flush() error {
+	return d.Call(func() {})
+}
+
+// Render renders the scene from cameraNode once all earlier calls have run, and waits for it
+func (d *Dispatcher) Render(cameraNode H3DNode) error {
+	return d.Call(func() {
+		Render(cameraNode)
+	})
+}
+
+// FinalizeFrame finishes the frame once all earlier calls have run, and waits for it
+func (d *Dispatcher) FinalizeFrame() error {
+	return d.Call(FinalizeFrame)
+}
@@ -0,0 +1,113 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestDispatcherOrder(t *testing.T) {
+	d := NewDispatcher(4)
+	d.Start()
+	var got []int
+	for i := 0; i < 100; i++ {
+		if err := d.Go(func() { got = append(got, i) }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	n, err := CallValue(d, func() int { return len(got) })
+	if err != nil || n != 100 {
+		t.Fatalf("CallValue = %d, %v", n, err)
+	}
+	d.Stop()
+	d.Wait()
+	for i, v := range got {
+		if v != i {
+			t.Fatalf("call %d ran as %d", v, i)
+		}
+	}
+	if err := d.Go(func() {}); !errors.Is(err, ErrDispatcherStopped) {
+		t.Errorf("Go after Stop = %v", err)
+	}
+	if err := d.Flush(); !errors.Is(err, ErrDispatcherStopped) {
+		t.Errorf("Flush after Stop = %v", err)
+	}
+}
+
+func TestDispatcherStop(t *testing.T) {
+	// stopping a dispatcher that never ran doesn't block
+	idle := NewDispatcher(1)
+	idle.Stop()
+	idle.Stop()
+	idle.Wait()
+
+	// stopping from a dispatched function ends Run once it returns
+	d := NewDispatcher(1)
+	d.Start()
+	if err := d.Call(d.Stop); err != nil {
+		t.Fatal(err)
+	}
+	d.Wait()
+
+	// calls queued before Stop still run, whatever goroutine queued them
+	d = NewDispatcher(0)
+	d.Start()
+	var mu sync.Mutex
+	ran, queued := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if d.Go(func() { mu.Lock(); ran++; mu.Unlock() }) == nil {
+					mu.Lock()
+					queued++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	d.Stop()
+	wg.Wait()
+	d.Wait()
+	if ran != queued {
+		t.Errorf("%d calls were queued but %d ran", queued, ran)
+	}
+}
+
+func TestDispatcherPanic(t *testing.T) {
+	d := NewDispatcher(1)
+	d.Start()
+	defer func() {
+		d.Stop()
+		d.Wait()
+	}()
+	defer func() {
+		if p := recover(); p != "boom" {
+			t.Errorf("recovered %v", p)
+		}
+	}()
+	d.Call(func() { panic("boom") })
+	t.Error("Call didn't pass the panic on")
+}
@@ -0,0 +1,212 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"errors"
+	"sync"
+)
+
+var (
+	ErrEngineInitialized = errors.New("horde3d: engine already initialized")
+	ErrEngineClosed      = errors.New("horde3d: engine closed")
+)
+
+// Config holds the engine options applied by NewEngine, see H3DOptions for their meaning
+type Config struct {
+	MaxLogLevel        int
+	MaxNumMessages     int
+	TrilinearFiltering bool
+	MaxAnisotropy      int
+	TexCompression     bool
+	SRGBLinearization  bool
+	LoadTextures       bool
+	FastAnimation      bool
+	ShadowMapSize      int
+	SampleCount        int
+	GatherTimeStats    bool
+}
+
+// DefaultConfig returns the engine defaults
+func DefaultConfig() Config {
+	return Config{
+		MaxLogLevel:        4,
+		MaxNumMessages:     512,
+		TrilinearFiltering: true,
+		MaxAnisotropy:      1,
+		TexCompression:     false,
+		SRGBLinearization:  false,
+		LoadTextures:       true,
+		FastAnimation:      true,
+		ShadowMapSize:      1024,
+		SampleCount:        0,
+		GatherTimeStats:    true,
+	}
+}
+
+type configOption struct {
+	param Option
+	value float32
+}
+
+func (cfg Config) options() []configOption {
+	return []configOption{
+		{Options_MaxLogLevel, float32(cfg.MaxLogLevel)},
+		{Options_MaxNumMessages, float32(cfg.MaxNumMessages)},
+		{Options_TrilinearFiltering, boolOption(cfg.TrilinearFiltering)},
+		{Options_MaxAnisotropy, float32(cfg.MaxAnisotropy)},
+		{Options_TexCompression, boolOption(cfg.TexCompression)},
+		{Options_SRGBLinearization, boolOption(cfg.SRGBLinearization)},
+		{Options_LoadTextures, boolOption(cfg.LoadTextures)},
+		{Options_FastAnimation, boolOption(cfg.FastAnimation)},
+		{Options_ShadowMapSize, float32(cfg.ShadowMapSize)},
+		{Options_SampleCount, float32(cfg.SampleCount)},
+		{Options_GatherTimeStats, boolOption(cfg.GatherTimeStats)},
+	}
+}
+
+func boolOption(b bool) float32 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Engine owns the initialized Horde3D engine.  Only one engine can be open at a time.
+type Engine struct {
+	closed bool
+}
+
+var (
+	engineMu   sync.Mutex
+	engineOpen bool
+)
+
+// NewEngine initializes the engine and applies cfg.  The OpenGL context has to be current on
+// the calling thread.
+func NewEngine(cfg Config) (*Engine, error) {
+	engineMu.Lock()
+	defer engineMu.Unlock()
+
+	if engineOpen {
+		return nil, ErrEngineInitialized
+	}
+	if err := InitErr(); err != nil {
+		return nil, err
+	}
+
+	e := &Engine{}
+	if err := e.apply(cfg); err != nil {
+		Release()
+		return nil, err
+	}
+	engineOpen = true
+	return e, nil
+}
+
+func (e *Engine) apply(cfg Config) error {
+	for _, opt := range cfg.options() {
+		if err := SetOptionErr(opt.param, opt.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close releases the engine and all of its resources and scene nodes
+func (e *Engine) Close() error {
+	engineMu.Lock()
+	defer engineMu.Unlock()
+
+	if e.closed {
+		return ErrEngineClosed
+	}
+	Release()
+	e.closed = true
+	engineOpen = false
+	return nil
+}
+
+// SetConfig applies cfg to the running engine.  Some options only affect resources loaded
+// afterwards.
+func (e *Engine) SetConfig(cfg Config) error {
+	engineMu.Lock()
+	defer engineMu.Unlock()
+
+	if e.closed {
+		return ErrEngineClosed
+	}
+	return e.apply(cfg)
+}
+
+// Option reads the current options back from the engine
+func (e *Engine) Option() (Config, error) {
+	engineMu.Lock()
+	defer engineMu.Unlock()
+
+	if e.closed {
+		return Config{}, ErrEngineClosed
+	}
+	return Config{
+		MaxLogLevel:        int(Options_MaxLogLevel.Value()),
+		MaxNumMessages:     int(Options_MaxNumMessages.Value()),
+		TrilinearFiltering: Options_TrilinearFiltering.Value() != 0,
+		MaxAnisotropy:      int(Options_MaxAnisotropy.Value()),
+		TexCompression:     Options_TexCompression.Value() != 0,
+		SRGBLinearization:  Options_SRGBLinearization.Value() != 0,
+		LoadTextures:       Options_LoadTextures.Value() != 0,
+		FastAnimation:      Options_FastAnimation.Value() != 0,
+		ShadowMapSize:      int(Options_ShadowMapSize.Value()),
+		SampleCount:        int(Options_SampleCount.Value()),
+		GatherTimeStats:    Options_GatherTimeStats.Value() != 0,
+	}, nil
+}
+
+func (e *Engine) Render(cameraNode H3DNode) error {
+	if e.isClosed() {
+		return ErrEngineClosed
+	}
+	Render(cameraNode)
+	return nil
+}
+
+func (e *Engine) FinalizeFrame() error {
+	if e.isClosed() {
+		return ErrEngineClosed
+	}
+	FinalizeFrame()
+	return nil
+}
+
+// Clear removes all resources and scene nodes but keeps the engine running
+func (e *Engine) Clear() error {
+	if e.isClosed() {
+		return ErrEngineClosed
+	}
+	Clear()
+	return nil
+}
+
+func (e *Engine) isClosed() bool {
+	engineMu.Lock()
+	defer engineMu.Unlock()
+	return e.closed
+}
@@ -0,0 +1,204 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+/*
+#include "goHorde3D.h"
+*/
+import "C"
+import (
+	"errors"
+	"strings"
+)
+
+// Errors returned by the error variants of the bool returning calls.  An *EngineError
+// unwraps to one of these, so they can be checked with errors.Is.
+var (
+	ErrFailed            = errors.New("horde3d: call failed")
+	ErrInitFailed        = errors.New("horde3d: engine could not be initialized")
+	ErrInvalidHandle     = errors.New("horde3d: invalid handle")
+	ErrResourceNotLoaded = errors.New("horde3d: resource not loaded")
+	ErrResourceLoad      = errors.New("horde3d: resource could not be loaded")
+	ErrShaderCompile     = errors.New("horde3d: shader compilation failed")
+	ErrInvalidOption     = errors.New("horde3d: invalid option")
+	ErrNotFound          = errors.New("horde3d: not found")
+	ErrWrongType         = errors.New("horde3d: wrong type")
+)
+
+// Log levels of the messages in the engine message queue.  Only messages with a level smaller or
+// equal to Options_MaxLogLevel are published.
+const (
+	LogLevel_Error = iota + 1
+	LogLevel_Warning
+	LogLevel_Info
+	LogLevel_Debug
+)
+
+// LogMessage is a single entry taken from the engine message queue
+type LogMessage struct {
+	Text  string
+	Level int
+	Time  float32 // engine time in seconds
+}
+
+// EngineError is returned when a Horde3D call reports failure.  Messages holds everything
+// that was in the message queue right after the failing call.
+type EngineError struct {
+	Op       string
+	Kind     error
+	Messages []LogMessage
+}
+
+func (e *EngineError) Error() string {
+	s := e.Kind.Error() + " in " + e.Op
+	if msg, ok := e.mostSevere(); ok {
+		s += ": " + msg.Text
+	}
+	return s
+}
+
+func (e *EngineError) Unwrap() error {
+	return e.Kind
+}
+
+func (e *EngineError) mostSevere() (LogMessage, bool) {
+	found := false
+	var severe LogMessage
+	for _, m := range e.Messages {
+		if !found || m.Level <= severe.Level {
+			severe = m
+			found = true
+		}
+	}
+	return severe, found
+}
+
+func drainMessages() []LogMessage {
+	var msgs []LogMessage
+	for {
+		var level C.int
+		var time C.float
+		text := C.GoString(C.h3dGetMessage(&level, &time))
+		if text == "" {
+			return msgs
+		}
+		msgs = append(msgs, LogMessage{Text: text, Level: int(level), Time: float32(time)})
+	}
+}
+
+// newEngineError drains the message queue and builds an error for the failed operation op.
+// kind is used unless one of the messages points to a more specific cause.
+func newEngineError(op string, kind error) error {
+	msgs := drainMessages()
+	return &EngineError{Op: op, Kind: classifyMessages(msgs, kind), Messages: msgs}
+}
+
+func classifyMessages(msgs []LogMessage, kind error) error {
+	for _, m := range msgs {
+		text := strings.ToLower(m.Text)
+		switch {
+		case strings.Contains(text, "shader") && strings.Contains(text, "compile"):
+			return ErrShaderCompile
+		case strings.Contains(text, "invalid resource handle"),
+			strings.Contains(text, "invalid node handle"),
+			strings.Contains(text, "invalid handle"):
+			return ErrInvalidHandle
+		case strings.Contains(text, "not loaded"), strings.Contains(text, "unloaded"):
+			return ErrResourceNotLoaded
+		}
+	}
+	return kind
+}
+
+func InitErr() error {
+	if !Init() {
+		return newEngineError("Init", ErrInitFailed)
+	}
+	return nil
+}
+
+func SetOptionErr(param Option, value float32) error {
+	if err := param.Validate(value); err != nil {
+		return err
+	}
+	if !SetOption(param, value) {
+		return newEngineError("SetOption", ErrInvalidOption)
+	}
+	return nil
+}
+
+func (res H3DRes) LoadErr(data []byte) error {
+	if !res.Load(data) {
+		return newEngineError("Load", ErrResourceLoad)
+	}
+	return nil
+}
+
+func (node H3DNode) SetParentErr(parent H3DNode) error {
+	if !node.SetParent(parent) {
+		return newEngineError("SetParent", ErrFailed)
+	}
+	return nil
+}
+
+func SetMaterialUniformErr(materialRes H3DRes, name string, a float32, b float32,
+	c float32, d float32) error {
+	if !SetMaterialUniform(materialRes, name, a, b, c, d) {
+		return newEngineError("SetMaterialUniform", ErrNotFound)
+	}
+	return nil
+}
+
+func RenderTargetDataErr(pipelineRes H3DRes, targetName string, bufIndex int, width *int,
+	height *int, compCount *int, dataBuffer []byte) error {
+	if !RenderTargetData(pipelineRes, targetName, bufIndex, width, height, compCount, dataBuffer) {
+		return newEngineError("RenderTargetData", ErrNotFound)
+	}
+	return nil
+}
+
+func SetModelMorpherErr(modelNode H3DNode, target string, weight float32) error {
+	if !SetModelMorpher(modelNode, target, weight) {
+		return newEngineError("SetModelMorpher", ErrNotFound)
+	}
+	return nil
+}
+
+func DumpMessagesErr() error {
+	if !DumpMessages() {
+		return newEngineError("DumpMessages", ErrFailed)
+	}
+	return nil
+}
+
+func LoadResourcesFromDiskErr(contentDir string) error {
+	if !LoadResourcesFromDisk(contentDir) {
+		return newEngineError("LoadResourcesFromDisk", ErrResourceLoad)
+	}
+	return nil
+}
+
+func ScreenshotErr(filename string) error {
+	if !Screenshot(filename) {
+		return newEngineError("Screenshot", ErrFailed)
+	}
+	return nil
+}
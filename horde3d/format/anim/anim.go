@@ -0,0 +1,253 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+// Package anim reads, writes and edits Horde3D H3DA animation files (.anim), without needing the
+// engine.
+//
+// An animation file holds one track per animated joint or mesh:
+//
+//	magic      [4]byte "H3DA"
+//	version    int32   3
+//	numTracks  int32
+//	numFrames  int32
+//	tracks     numTracks * (name [256]byte, static uint8, frames)
+//
+// A static track is stored compressed as a single frame, the others have numFrames frames.
+// Version 2 files, which the engine still loads, have no static flag and no compression.  Each
+// frame is the rotation quaternion x, y, z, w, followed by the translation and the scale, all as
+// float32.  All values are little endian.  Decoding and encoding a file gives back the same bytes.
+package anim
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"bitbucket.org/tshannon/gohorde/horde3d/math"
+)
+
+const (
+	magic   = "H3DA"
+	Version = 3
+
+	nameSize = 256
+	maxCount = 1 << 24
+
+	// frameChunk limits the frames Decode reads at once, so a bad count in the header runs
+	// out of data before it allocates much
+	frameChunk = 4096
+)
+
+var (
+	ErrFormat  = errors.New("anim: not a valid H3DA file")
+	ErrVersion = errors.New("anim: unsupported version")
+)
+
+// Animation is the content of an .anim file
+type Animation struct {
+	// Version is the file version, Encode writes version 2 files if it is 2 and version 3
+	// files otherwise
+	Version    int
+	FrameCount int
+	Tracks     []Track
+}
+
+// Track holds the frames of a single joint or mesh, named like the node it animates
+type Track struct {
+	Name string
+	// Static tracks have a single frame that is used for the whole animation, and are stored
+	// compressed
+	Static bool
+	Frames []Frame
+
+	// rawName keeps the padding bytes of the name field as they were read
+	rawName [nameSize]byte
+}
+
+// Frame is the transformation of a node relative to its parent
+type Frame struct {
+	Rotation    math.Quat
+	Translation math.Vec3
+	Scale       math.Vec3
+}
+
+// Frame returns frame i of the track, which is the single frame for static tracks
+func (t *Track) Frame(i int) Frame {
+	if t.Static {
+		return t.Frames[0]
+	}
+	return t.Frames[i]
+}
+
+// Track returns the track with the given name, or nil
+func (a *Animation) Track(name string) *Track {
+	for i := range a.Tracks {
+		if a.Tracks[i].Name == name {
+			return &a.Tracks[i]
+		}
+	}
+	return nil
+}
+
+// Decode reads an animation file
+func Decode(r io.Reader) (*Animation, error) {
+	br := bufio.NewReader(r)
+	read := func(v any) error {
+		if err := binary.Read(br, binary.LittleEndian, v); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return fmt.Errorf("%w: unexpected end of file", ErrFormat)
+			}
+			return err
+		}
+		return nil
+	}
+
+	var header struct {
+		Magic     [4]byte
+		Version   int32
+		NumTracks int32
+		NumFrames int32
+	}
+	if err := read(&header); err != nil {
+		return nil, err
+	}
+	if string(header.Magic[:]) != magic {
+		return nil, ErrFormat
+	}
+	if header.Version != 2 && header.Version != Version {
+		return nil, fmt.Errorf("%w %d", ErrVersion, header.Version)
+	}
+	if header.NumTracks < 0 || header.NumTracks > maxCount ||
+		header.NumFrames < 0 || header.NumFrames > maxCount {
+		return nil, fmt.Errorf("%w: bad count", ErrFormat)
+	}
+
+	a := &Animation{Version: int(header.Version), FrameCount: int(header.NumFrames)}
+	for i := 0; i < int(header.NumTracks); i++ {
+		var t Track
+		var static uint8
+		if err := read(&t.rawName); err != nil {
+			return nil, err
+		}
+		if a.Version >= 3 {
+			if err := read(&static); err != nil {
+				return nil, err
+			}
+		}
+		if static > 1 {
+			return nil, fmt.Errorf("%w: bad static flag %d", ErrFormat, static)
+		}
+		t.Name = cString(t.rawName[:])
+		t.Static = static == 1
+
+		frames := a.FrameCount
+		if t.Static {
+			frames = 1
+		}
+		for len(t.Frames) < frames {
+			chunk := make([]Frame, min(frames-len(t.Frames), frameChunk))
+			if err := read(chunk); err != nil {
+				return nil, err
+			}
+			t.Frames = append(t.Frames, chunk...)
+		}
+		a.Tracks = append(a.Tracks, t)
+	}
+	return a, nil
+}
+
+// Encode writes a as an animation file
+func Encode(w io.Writer, a *Animation) error {
+	if err := a.Validate(); err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	var err error
+	write := func(v any) {
+		if err == nil {
+			err = binary.Write(bw, binary.LittleEndian, v)
+		}
+	}
+	version := int32(Version)
+	if a.Version == 2 {
+		version = 2
+	}
+	write([]byte(magic))
+	write([]int32{version, int32(len(a.Tracks)), int32(a.FrameCount)})
+	for i := range a.Tracks {
+		t := &a.Tracks[i]
+		raw := t.rawName
+		setName(&raw, t.Name)
+		write(raw)
+		switch {
+		case version == 2:
+		case t.Static:
+			write(uint8(1))
+		default:
+			write(uint8(0))
+		}
+		write(t.Frames)
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Validate checks that every track has the right number of frames and a name that fits the file
+func (a *Animation) Validate() error {
+	for i := range a.Tracks {
+		t := &a.Tracks[i]
+		if len(t.Name) >= nameSize {
+			return fmt.Errorf("anim: track name %q is too long", t.Name)
+		}
+		switch {
+		case t.Static && a.Version == 2:
+			return fmt.Errorf("anim: static track %q can't be stored in a version 2 file", t.Name)
+		case t.Static && len(t.Frames) != 1:
+			return fmt.Errorf("anim: static track %q has %d frames", t.Name, len(t.Frames))
+		case !t.Static && len(t.Frames) != a.FrameCount:
+			return fmt.Errorf("anim: track %q has %d frames instead of %d", t.Name, len(t.Frames),
+				a.FrameCount)
+		}
+	}
+	return nil
+}
+
+// setName stores name in a name field, keeping the previous padding if the name didn't change
+func setName(raw *[nameSize]byte, name string) {
+	if cString(raw[:]) == name {
+		return
+	}
+	*raw = [nameSize]byte{}
+	copy(raw[:nameSize-1], name)
+}
+
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
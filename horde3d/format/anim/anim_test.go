@@ -0,0 +1,213 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package anim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"bitbucket.org/tshannon/gohorde/horde3d/math"
+)
+
+const animDir = "../../../examples/content/animations"
+
+func readAnim(t *testing.T, name string) (*Animation, []byte) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(animDir, name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return a, data
+}
+
+func encode(t *testing.T, a *Animation) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Encode(&buf, a); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, name := range []string{"knight_attack.anim", "knight_order.anim"} {
+		a, data := readAnim(t, name)
+		if a.FrameCount == 0 || len(a.Tracks) == 0 {
+			t.Fatalf("%s: %d frames, %d tracks", name, a.FrameCount, len(a.Tracks))
+		}
+		if !bytes.Equal(encode(t, a), data) {
+			t.Errorf("%s: encoding doesn't give back the same bytes", name)
+		}
+	}
+}
+
+func TestHugeCounts(t *testing.T) {
+	for _, header := range [][]int32{{Version, 1 << 24, 1}, {Version, 1, 1 << 24}} {
+		var buf bytes.Buffer
+		buf.WriteString(magic)
+		binary.Write(&buf, binary.LittleEndian, header)
+		if header[1] == 1 {
+			buf.Write(make([]byte, nameSize+1))
+		}
+
+		size := buf.Len()
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		_, err := Decode(&buf)
+		runtime.ReadMemStats(&after)
+		if !errors.Is(err, ErrFormat) {
+			t.Errorf("%v: decodes with %v", header, err)
+		}
+		if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 1<<20 {
+			t.Errorf("%v: allocated %d bytes for a %d byte file", header, alloc, size)
+		}
+	}
+}
+
+// testAnim has a moving track "a" and a static track "b"
+func testAnim(frames int, offset float32) *Animation {
+	a := &Animation{FrameCount: frames}
+	moving := Track{Name: "a"}
+	for i := 0; i < frames; i++ {
+		moving.Frames = append(moving.Frames, Frame{
+			Rotation:    math.QuatIdentity(),
+			Translation: math.Vec3{X: offset + float32(i)},
+			Scale:       math.Vec3{X: 1, Y: 1, Z: 1},
+		})
+	}
+	static := Track{Name: "b", Static: true, Frames: []Frame{{Rotation: math.QuatIdentity()}}}
+	a.Tracks = []Track{moving, static}
+	return a
+}
+
+func TestVersion2(t *testing.T) {
+	a := testAnim(3, 0)
+	a.Version = 2
+	a.Tracks[1] = Track{Name: "b", Frames: make([]Frame, 3)}
+	data := encode(t, a)
+	if v := binary.LittleEndian.Uint32(data[4:]); v != 2 {
+		t.Fatalf("encoded version %d", v)
+	}
+	b, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Version != 2 || len(b.Tracks) != 2 || len(b.Tracks[1].Frames) != 3 {
+		t.Fatalf("decoded %+v", b)
+	}
+	if !bytes.Equal(encode(t, b), data) {
+		t.Error("version 2 file doesn't round-trip")
+	}
+
+	b.Compress()
+	if !b.Tracks[1].Static || b.Version != Version {
+		t.Errorf("compressing gives version %d, static %v", b.Version, b.Tracks[1].Static)
+	}
+
+	data[4] = 1
+	if _, err := Decode(bytes.NewReader(data)); !errors.Is(err, ErrVersion) {
+		t.Errorf("version 1 decodes with %v", err)
+	}
+}
+
+func TestTrim(t *testing.T) {
+	a := testAnim(10, 0)
+	trimmed, err := a.Trim(2, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trimmed.FrameCount != 3 || trimmed.Tracks[0].Frames[0].Translation.X != 2 ||
+		len(trimmed.Tracks[1].Frames) != 1 {
+		t.Errorf("trimmed to %+v", trimmed)
+	}
+	trimmed.Tracks[0].Frames[0].Translation.X = 100
+	if a.Tracks[0].Frames[2].Translation.X != 2 {
+		t.Error("trimming shares frames with the original")
+	}
+	if _, err := a.Trim(5, 11); err == nil {
+		t.Error("trimming past the end succeeded")
+	}
+}
+
+func TestResample(t *testing.T) {
+	a := testAnim(5, 0)
+	resampled, err := a.Resample(9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resampled.FrameCount != 9 || resampled.Validate() != nil {
+		t.Fatalf("resampled to %+v", resampled)
+	}
+	for i, f := range resampled.Tracks[0].Frames {
+		if want := float32(i) / 2; f.Translation.X != want {
+			t.Errorf("frame %d at %v, want %v", i, f.Translation.X, want)
+		}
+	}
+	if _, err := a.Resample(0); err == nil {
+		t.Error("resampling to no frames succeeded")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	upper, lower := testAnim(4, 0), testAnim(4, 10)
+	lower.Tracks[0].Name = "c"
+	lower.Tracks[1].Frames[0].Scale = math.Vec3{X: 2}
+	merged, err := Merge(upper, lower)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(merged.Tracks) != 3 || merged.Track("c").Frames[0].Translation.X != 10 ||
+		merged.Track("b").Frames[0].Scale.X != 2 {
+		t.Errorf("merged to %+v", merged)
+	}
+	if _, err := Merge(upper, testAnim(5, 0)); err == nil {
+		t.Error("merging different frame counts succeeded")
+	}
+}
+
+func TestConcat(t *testing.T) {
+	joined, err := Concat(testAnim(3, 0), testAnim(2, 10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if joined.FrameCount != 5 || joined.Validate() != nil || !joined.Tracks[1].Static {
+		t.Fatalf("concatenated to %+v", joined)
+	}
+	if x := joined.Tracks[0].Frames[3].Translation.X; x != 10 {
+		t.Errorf("frame 3 at %v, want 10", x)
+	}
+
+	other := testAnim(2, 0)
+	other.Tracks[1].Frames[0].Translation.X = 1
+	joined, err = Concat(testAnim(3, 0), other)
+	if err != nil || joined.Tracks[1].Static || len(joined.Tracks[1].Frames) != 5 {
+		t.Errorf("track that differs between clips: %+v, %v", joined, err)
+	}
+}
@@ -0,0 +1,168 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package anim
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Trim returns the frames from start up to, but not including, end
+func (a *Animation) Trim(start int, end int) (*Animation, error) {
+	if start < 0 || end > a.FrameCount || start >= end {
+		return nil, fmt.Errorf("anim: invalid frame range %d-%d of %d frames", start, end, a.FrameCount)
+	}
+	trimmed := &Animation{FrameCount: end - start, Tracks: make([]Track, len(a.Tracks))}
+	for i, t := range a.Tracks {
+		if !t.Static {
+			t.Frames = t.Frames[start:end]
+		}
+		trimmed.Tracks[i] = t.clone()
+	}
+	return trimmed, nil
+}
+
+// Resample returns the animation stretched or squeezed to frameCount frames.  The rotations of
+// the new frames are interpolated with Slerp, translation and scale linearly.
+func (a *Animation) Resample(frameCount int) (*Animation, error) {
+	if frameCount <= 0 || a.FrameCount <= 0 {
+		return nil, fmt.Errorf("anim: can't resample %d frames to %d", a.FrameCount, frameCount)
+	}
+	resampled := &Animation{FrameCount: frameCount, Tracks: make([]Track, len(a.Tracks))}
+	for i, t := range a.Tracks {
+		if t.Static {
+			resampled.Tracks[i] = t.clone()
+			continue
+		}
+		frames := make([]Frame, frameCount)
+		for f := range frames {
+			pos := float32(0)
+			if frameCount > 1 {
+				pos = float32(f) * float32(a.FrameCount-1) / float32(frameCount-1)
+			}
+			i0 := int(pos)
+			i1 := min(i0+1, a.FrameCount-1)
+			frames[f] = interpolate(t.Frames[i0], t.Frames[i1], pos-float32(i0))
+		}
+		t.Frames = frames
+		resampled.Tracks[i] = t
+	}
+	return resampled, nil
+}
+
+func interpolate(a Frame, b Frame, w float32) Frame {
+	if w == 0 {
+		return a
+	}
+	return Frame{
+		Rotation:    a.Rotation.Slerp(b.Rotation, w),
+		Translation: a.Translation.Lerp(b.Translation, w),
+		Scale:       a.Scale.Lerp(b.Scale, w),
+	}
+}
+
+// Merge combines the tracks of clips with the same number of frames into one animation, for
+// example the upper body of one clip with the legs of another.  Tracks of later clips replace
+// tracks of the same name in earlier ones.
+func Merge(clips ...*Animation) (*Animation, error) {
+	if len(clips) == 0 {
+		return nil, fmt.Errorf("anim: nothing to merge")
+	}
+	merged := &Animation{FrameCount: clips[0].FrameCount}
+	for _, c := range clips {
+		if c.FrameCount != merged.FrameCount {
+			return nil, fmt.Errorf("anim: can't merge clips with %d and %d frames", merged.FrameCount,
+				c.FrameCount)
+		}
+		for _, t := range c.Tracks {
+			if existing := merged.Track(t.Name); existing != nil {
+				*existing = t.clone()
+				continue
+			}
+			merged.Tracks = append(merged.Tracks, t.clone())
+		}
+	}
+	return merged, nil
+}
+
+// Concat plays clips animating the same tracks one after another.  A track stays static if it
+// has the same frame in all clips.
+func Concat(clips ...*Animation) (*Animation, error) {
+	if len(clips) == 0 {
+		return nil, fmt.Errorf("anim: nothing to concatenate")
+	}
+	joined := &Animation{}
+	for _, c := range clips {
+		if len(c.Tracks) != len(clips[0].Tracks) {
+			return nil, fmt.Errorf("anim: can't concatenate clips with different tracks")
+		}
+		joined.FrameCount += c.FrameCount
+	}
+
+	for _, first := range clips[0].Tracks {
+		t := first.clone()
+		t.Frames = nil
+		static := true
+		for _, c := range clips {
+			ct := c.Track(first.Name)
+			if ct == nil {
+				return nil, fmt.Errorf("anim: track %q is missing from a clip", first.Name)
+			}
+			static = static && ct.Static && ct.Frames[0] == first.Frames[0]
+		}
+		if static {
+			t.Frames = []Frame{first.Frames[0]}
+		} else {
+			t.Static = false
+			for _, c := range clips {
+				ct := c.Track(first.Name)
+				for f := 0; f < c.FrameCount; f++ {
+					t.Frames = append(t.Frames, ct.Frame(f))
+				}
+			}
+		}
+		joined.Tracks = append(joined.Tracks, t)
+	}
+	return joined, nil
+}
+
+// Compress turns tracks whose frames are all the same into static tracks, upgrading version 2
+// animations to version 3, which can store them
+func (a *Animation) Compress() {
+	for i := range a.Tracks {
+		t := &a.Tracks[i]
+		if t.Static || len(t.Frames) == 0 {
+			continue
+		}
+		if !slices.ContainsFunc(t.Frames, func(f Frame) bool { return f != t.Frames[0] }) {
+			t.Static = true
+			t.Frames = t.Frames[:1:1]
+			if a.Version == 2 {
+				a.Version = Version
+			}
+		}
+	}
+}
+
+func (t Track) clone() Track {
+	t.Frames = slices.Clone(t.Frames)
+	return t
+}
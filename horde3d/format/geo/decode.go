@@ -0,0 +1,204 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package geo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"bitbucket.org/tshannon/gohorde/horde3d/math"
+)
+
+const (
+	// maxCount guards against corrupt counts
+	maxCount = 1 << 26
+
+	// chunkSize limits the bytes read into a slice at once, so a bad count runs out of data
+	// before it allocates much
+	chunkSize = 64 << 10
+)
+
+type decoder struct {
+	r   io.Reader
+	err error
+}
+
+func (d *decoder) read(v any) {
+	if d.err == nil {
+		d.err = binary.Read(d.r, binary.LittleEndian, v)
+	}
+}
+
+func (d *decoder) count() int {
+	var n int32
+	d.read(&n)
+	if d.err == nil && (n < 0 || n > maxCount) {
+		d.err = fmt.Errorf("%w: bad count %d", ErrFormat, n)
+	}
+	if d.err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// Decode reads a geometry file
+func Decode(r io.Reader) (*Geometry, error) {
+	d := &decoder{r: bufio.NewReader(r)}
+
+	var header [4]byte
+	var version int32
+	d.read(&header)
+	d.read(&version)
+	if d.err != nil {
+		return nil, d.error()
+	}
+	if string(header[:]) != magic {
+		return nil, ErrFormat
+	}
+	if version != Version {
+		return nil, fmt.Errorf("%w %d", ErrVersion, version)
+	}
+
+	g := &Geometry{}
+	g.Joints = readSlice[math.Mat4](d, d.count())
+
+	numStreams := d.count()
+	g.VertexCount = d.count()
+	for i := 0; i < numStreams && d.err == nil; i++ {
+		g.decodeStream(d)
+	}
+
+	g.Indices = readSlice[uint32](d, d.count())
+
+	numMorphs := d.count()
+	for i := 0; i < numMorphs && d.err == nil; i++ {
+		g.MorphTargets = append(g.MorphTargets, decodeMorphTarget(d))
+	}
+	if d.err != nil {
+		return nil, d.error()
+	}
+	return g, nil
+}
+
+func (d *decoder) error() error {
+	if errors.Is(d.err, io.EOF) || errors.Is(d.err, io.ErrUnexpectedEOF) {
+		return fmt.Errorf("%w: unexpected end of file", ErrFormat)
+	}
+	return d.err
+}
+
+func (g *Geometry) decodeStream(d *decoder) {
+	id, size := d.count(), d.count()
+	if d.err != nil {
+		return
+	}
+	if slices.Contains(g.Streams, id) {
+		d.err = fmt.Errorf("%w: duplicate stream %d", ErrFormat, id)
+		return
+	}
+
+	n := g.VertexCount
+	switch {
+	case id == StreamPosition && size == 12:
+		g.Positions = readSlice[math.Vec3](d, n)
+	case id == StreamNormal && size == 6:
+		g.Normals = readSlice[[3]int16](d, n)
+	case id == StreamTangent && size == 6:
+		g.Tangents = readSlice[[3]int16](d, n)
+	case id == StreamBitangent && size == 6:
+		g.Bitangents = readSlice[[3]int16](d, n)
+	case id == StreamJointIndices && size == 4:
+		g.JointIndices = readSlice[[4]uint8](d, n)
+	case id == StreamJointWeights && size == 4:
+		g.JointWeights = readSlice[[4]uint8](d, n)
+	case id == StreamTexCoords0 && size == 8:
+		g.TexCoords0 = readSlice[math.Vec2](d, n)
+	case id == StreamTexCoords1 && size == 8:
+		g.TexCoords1 = readSlice[math.Vec2](d, n)
+	case id <= StreamTexCoords1:
+		d.err = fmt.Errorf("%w: stream %d has element size %d", ErrFormat, id, size)
+		return
+	default:
+		g.Extra = append(g.Extra, readRawStream(d, id, size, n))
+	}
+	g.Streams = append(g.Streams, id)
+}
+
+func decodeMorphTarget(d *decoder) MorphTarget {
+	var m MorphTarget
+	d.read(&m.rawName)
+	m.Name = cString(m.rawName[:])
+	numStreams := d.count()
+	m.VertexIndices = readSlice[uint32](d, d.count())
+
+	n := len(m.VertexIndices)
+	for i := 0; i < numStreams && d.err == nil; i++ {
+		id, size := d.count(), d.count()
+		if d.err != nil {
+			break
+		}
+		if slices.Contains(m.Streams, id) {
+			d.err = fmt.Errorf("%w: duplicate morph stream %d", ErrFormat, id)
+			break
+		}
+		switch {
+		case id == MorphPosition && size == 12:
+			m.Positions = readSlice[math.Vec3](d, n)
+		case id == MorphNormal && size == 12:
+			m.Normals = readSlice[math.Vec3](d, n)
+		case id == MorphTangent && size == 12:
+			m.Tangents = readSlice[math.Vec3](d, n)
+		case id == MorphBitangent && size == 12:
+			m.Bitangents = readSlice[math.Vec3](d, n)
+		case id <= MorphBitangent:
+			d.err = fmt.Errorf("%w: morph stream %d has element size %d", ErrFormat, id, size)
+			return m
+		default:
+			m.Extra = append(m.Extra, readRawStream(d, id, size, n))
+		}
+		m.Streams = append(m.Streams, id)
+	}
+	return m
+}
+
+// readSlice reads n elements, growing the slice as the data comes in
+func readSlice[T any](d *decoder, n int) []T {
+	per := max(chunkSize/binary.Size(*new(T)), 1)
+	s := make([]T, 0, min(n, per))
+	for len(s) < n && d.err == nil {
+		chunk := make([]T, min(n-len(s), per))
+		d.read(chunk)
+		s = append(s, chunk...)
+	}
+	return s
+}
+
+func readRawStream(d *decoder, id int, size int, n int) RawStream {
+	if size*n > maxCount {
+		d.err = fmt.Errorf("%w: stream %d too large", ErrFormat, id)
+		return RawStream{}
+	}
+	return RawStream{ID: id, ElemSize: size, Data: readSlice[byte](d, size*n)}
+}
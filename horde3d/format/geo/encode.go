@@ -0,0 +1,174 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package geo
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+)
+
+type encoder struct {
+	w   *bufio.Writer
+	err error
+}
+
+func (e *encoder) write(v any) {
+	if e.err == nil {
+		e.err = binary.Write(e.w, binary.LittleEndian, v)
+	}
+}
+
+func (e *encoder) int32(v int) {
+	e.write(int32(v))
+}
+
+// stream is a vertex stream ready to be written
+type stream struct {
+	id       int
+	elemSize int
+	length   int
+	data     any
+}
+
+// Encode writes g as a geometry file
+func Encode(w io.Writer, g *Geometry) error {
+	streams, err := g.streams()
+	if err != nil {
+		return err
+	}
+	morphs := make([][]stream, len(g.MorphTargets))
+	for i := range g.MorphTargets {
+		if morphs[i], err = g.MorphTargets[i].streams(); err != nil {
+			return err
+		}
+	}
+
+	e := &encoder{w: bufio.NewWriter(w)}
+	e.write([]byte(magic))
+	e.int32(Version)
+	e.int32(len(g.Joints))
+	e.write(g.Joints)
+
+	e.int32(len(streams))
+	e.int32(g.VertexCount)
+	e.writeStreams(streams)
+
+	e.int32(len(g.Indices))
+	e.write(g.Indices)
+
+	e.int32(len(g.MorphTargets))
+	for i := range g.MorphTargets {
+		m := &g.MorphTargets[i]
+		raw := m.rawName
+		setName(&raw, m.Name)
+		e.write(raw)
+		e.int32(len(morphs[i]))
+		e.int32(len(m.VertexIndices))
+		e.write(m.VertexIndices)
+		e.writeStreams(morphs[i])
+	}
+
+	if e.err != nil {
+		return e.err
+	}
+	return e.w.Flush()
+}
+
+func (e *encoder) writeStreams(streams []stream) {
+	for _, s := range streams {
+		e.int32(s.id)
+		e.int32(s.elemSize)
+		e.write(s.data)
+	}
+}
+
+func (g *Geometry) streams() ([]stream, error) {
+	all := []stream{
+		{StreamPosition, 12, len(g.Positions), g.Positions},
+		{StreamNormal, 6, len(g.Normals), g.Normals},
+		{StreamTangent, 6, len(g.Tangents), g.Tangents},
+		{StreamBitangent, 6, len(g.Bitangents), g.Bitangents},
+		{StreamJointIndices, 4, len(g.JointIndices), g.JointIndices},
+		{StreamJointWeights, 4, len(g.JointWeights), g.JointWeights},
+		{StreamTexCoords0, 8, len(g.TexCoords0), g.TexCoords0},
+		{StreamTexCoords1, 8, len(g.TexCoords1), g.TexCoords1},
+	}
+	for _, raw := range g.Extra {
+		if raw.ID <= StreamTexCoords1 {
+			return nil, fmt.Errorf("geo: extra stream %d uses the id of a known stream", raw.ID)
+		}
+		all = append(all, rawStream(raw))
+	}
+	return orderStreams(all, g.Streams, g.VertexCount, "vertex")
+}
+
+func (m *MorphTarget) streams() ([]stream, error) {
+	all := []stream{
+		{MorphPosition, 12, len(m.Positions), m.Positions},
+		{MorphNormal, 12, len(m.Normals), m.Normals},
+		{MorphTangent, 12, len(m.Tangents), m.Tangents},
+		{MorphBitangent, 12, len(m.Bitangents), m.Bitangents},
+	}
+	for _, raw := range m.Extra {
+		if raw.ID <= MorphBitangent {
+			return nil, fmt.Errorf("geo: extra morph stream %d uses the id of a known stream", raw.ID)
+		}
+		all = append(all, rawStream(raw))
+	}
+	return orderStreams(all, m.Streams, len(m.VertexIndices), "morph target "+m.Name)
+}
+
+func rawStream(raw RawStream) stream {
+	length := -1
+	if raw.ElemSize > 0 && len(raw.Data)%raw.ElemSize == 0 {
+		length = len(raw.Data) / raw.ElemSize
+	}
+	return stream{raw.ID, raw.ElemSize, length, raw.Data}
+}
+
+// orderStreams drops the empty streams that aren't listed in order, checks the length of the others and sorts them by order
+func orderStreams(all []stream, order []int, count int, what string) ([]stream, error) {
+	var streams []stream
+	for _, s := range all {
+		if s.length == 0 && !slices.Contains(order, s.id) {
+			continue
+		}
+		if s.length != count {
+			return nil, fmt.Errorf("geo: stream %d of the %s data does not have %d elements", s.id, what,
+				count)
+		}
+		streams = append(streams, s)
+	}
+	slices.SortStableFunc(streams, func(a, b stream) int {
+		return rank(order, a.id) - rank(order, b.id)
+	})
+	return streams, nil
+}
+
+func rank(order []int, id int) int {
+	if i := slices.Index(order, id); i >= 0 {
+		return i
+	}
+	return len(order) + id
+}
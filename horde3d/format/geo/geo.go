@@ -0,0 +1,161 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+// Package geo reads and writes Horde3D H3DG geometry files (.geo), without needing the engine.
+//
+// A geometry file holds the inverse bind matrices of the joints, the vertex streams, the
+// triangle indices and the morph targets:
+//
+//	magic      [4]byte "H3DG"
+//	version    int32   5
+//	numJoints  int32
+//	joints     numJoints * [16]float32
+//	numStreams int32
+//	numVerts   int32
+//	streams    numStreams * (id int32, elemSize int32, numVerts * elemSize bytes)
+//	numIndices int32
+//	indices    numIndices * uint32
+//	numMorphs  int32
+//	morphs     numMorphs * (name [256]byte, numStreams int32, numVerts int32,
+//	           vertIndices numVerts * uint32, streams numStreams * (id, elemSize, data))
+//
+// All values are little endian.  Decoding and encoding a file gives back the same bytes.
+package geo
+
+import (
+	"errors"
+
+	"bitbucket.org/tshannon/gohorde/horde3d/math"
+)
+
+const (
+	magic   = "H3DG"
+	Version = 5
+
+	nameSize = 256
+)
+
+var (
+	ErrFormat  = errors.New("geo: not a valid H3DG file")
+	ErrVersion = errors.New("geo: unsupported version")
+)
+
+// Vertex stream ids
+const (
+	StreamPosition     = 0 // math.Vec3
+	StreamNormal       = 1 // [3]int16
+	StreamTangent      = 2 // [3]int16
+	StreamBitangent    = 3 // [3]int16
+	StreamJointIndices = 4 // [4]uint8
+	StreamJointWeights = 5 // [4]uint8, 255 is a weight of one
+	StreamTexCoords0   = 6 // math.Vec2
+	StreamTexCoords1   = 7 // math.Vec2
+)
+
+// Morph target stream ids, all of them math.Vec3
+const (
+	MorphPosition  = 0
+	MorphNormal    = 1
+	MorphTangent   = 2
+	MorphBitangent = 3
+)
+
+// Geometry is the content of a .geo file.  The vertex streams that are present have VertexCount
+// elements, missing streams are nil.
+type Geometry struct {
+	Joints      []math.Mat4 // inverse bind matrices, column major
+	VertexCount int
+
+	Positions    []math.Vec3
+	Normals      [][3]int16 // unit vectors scaled by 32767, see EncodeNormal
+	Tangents     [][3]int16
+	Bitangents   [][3]int16
+	JointIndices [][4]uint8
+	JointWeights [][4]uint8
+	TexCoords0   []math.Vec2
+	TexCoords1   []math.Vec2
+
+	// Extra holds the streams with ids the engine doesn't know, which it skips when loading
+	Extra []RawStream
+
+	// Streams lists the stream ids in file order.  Encode writes the streams that are present
+	// in this order, followed by the ones not listed.
+	Streams []int
+
+	Indices      []uint32
+	MorphTargets []MorphTarget
+}
+
+// MorphTarget holds the differences of a subset of the vertices
+type MorphTarget struct {
+	Name          string
+	VertexIndices []uint32
+
+	Positions  []math.Vec3
+	Normals    []math.Vec3
+	Tangents   []math.Vec3
+	Bitangents []math.Vec3
+	Extra      []RawStream
+	Streams    []int
+
+	// rawName keeps the padding bytes of the name field as they were read
+	rawName [nameSize]byte
+}
+
+// RawStream is a stream stored as it appears in the file
+type RawStream struct {
+	ID       int
+	ElemSize int
+	Data     []byte
+}
+
+// EncodeNormal converts a unit vector to the int16 format of the normal, tangent and bitangent
+// streams
+func EncodeNormal(v math.Vec3) [3]int16 {
+	return [3]int16{toInt16(v.X), toInt16(v.Y), toInt16(v.Z)}
+}
+
+// DecodeNormal converts a normal, tangent or bitangent back to a vector
+func DecodeNormal(n [3]int16) math.Vec3 {
+	return math.Vec3{X: float32(n[0]) / 32767, Y: float32(n[1]) / 32767, Z: float32(n[2]) / 32767}
+}
+
+func toInt16(f float32) int16 {
+	f = max(-1, min(1, f))
+	return int16(f * 32767)
+}
+
+// setName stores name in a name field, keeping the previous padding if the name didn't change
+func setName(raw *[nameSize]byte, name string) {
+	if cString(raw[:]) == name {
+		return
+	}
+	*raw = [nameSize]byte{}
+	copy(raw[:nameSize-1], name)
+}
+
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,104 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package geo
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"bitbucket.org/tshannon/gohorde/horde3d/math"
+)
+
+func TestRoundTrip(t *testing.T) {
+	for _, name := range []string{"knight", "platform", "skybox", "sphere"} {
+		data, err := os.ReadFile(filepath.Join("../../../examples/content/models", name, name+".geo"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		g, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if g.VertexCount == 0 || len(g.Positions) != g.VertexCount || len(g.Indices) == 0 {
+			t.Errorf("%s: %d vertices, %d positions, %d indices", name, g.VertexCount,
+				len(g.Positions), len(g.Indices))
+		}
+		var buf bytes.Buffer
+		if err := Encode(&buf, g); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(buf.Bytes(), data) {
+			t.Errorf("%s: encoding doesn't give back the same bytes", name)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	if _, err := Decode(bytes.NewReader([]byte("H3DA\x05\x00\x00\x00"))); !errors.Is(err, ErrFormat) {
+		t.Errorf("wrong magic decodes with %v", err)
+	}
+	if _, err := Decode(bytes.NewReader([]byte("H3DG\x04\x00\x00\x00"))); !errors.Is(err, ErrVersion) {
+		t.Errorf("version 4 decodes with %v", err)
+	}
+	truncated := []byte("H3DG\x05\x00\x00\x00\x01")
+	if _, err := Decode(bytes.NewReader(truncated)); !errors.Is(err, ErrFormat) {
+		t.Errorf("truncated file decodes with %v", err)
+	}
+}
+
+func TestHugeCounts(t *testing.T) {
+	for _, counts := range [][]int32{
+		{1 << 26},                                 // joints
+		{0, 1, 1 << 26, StreamPosition, 12},       // vertices
+		{0, 0, 0, 1 << 26},                        // indices
+		{0, 1, 1 << 20, StreamTexCoords1 + 1, 64}, // extra stream
+	} {
+		var buf bytes.Buffer
+		buf.WriteString(magic)
+		binary.Write(&buf, binary.LittleEndian, append([]int32{Version}, counts...))
+		size := buf.Len()
+
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		_, err := Decode(&buf)
+		runtime.ReadMemStats(&after)
+		if !errors.Is(err, ErrFormat) {
+			t.Errorf("%v: decodes with %v", counts, err)
+		}
+		if alloc := after.TotalAlloc - before.TotalAlloc; alloc > 1<<20 {
+			t.Errorf("%v: allocated %d bytes for a %d byte file", counts, alloc, size)
+		}
+	}
+}
+
+func TestEncodeNormal(t *testing.T) {
+	for _, v := range []math.Vec3{{X: 1}, {Y: -1}, {X: 0.6, Z: 0.8}} {
+		back := DecodeNormal(EncodeNormal(v))
+		if back.Sub(v).Len() > 1e-4 {
+			t.Errorf("%v encodes to %v", v, back)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package obj
+
+import (
+	"errors"
+
+	"bitbucket.org/tshannon/gohorde/horde3d/format/geo"
+	"bitbucket.org/tshannon/gohorde/horde3d/math"
+)
+
+// Batch is the range of a geometry drawn with one material, as set by a Mesh node
+type Batch struct {
+	Material  string
+	Start     int // first index
+	Count     int // number of indices
+	VertStart int // first vertex used
+	VertEnd   int // last vertex used
+}
+
+// vertexKey identifies a vertex of the geometry.  Corners without a normal share the smooth
+// normal computed for their position.
+type vertexKey struct {
+	position, texCoord, normal int
+}
+
+// Geometry converts the faces to a triangle geometry with one batch per material, in the order
+// the materials are first used.  Every batch uses its own range of vertices, shared between the
+// corners with the same attributes.  Missing normals are computed by averaging the normals of
+// the faces around a position, and if the model has texture coordinates, tangents and
+// bitangents are computed for normal mapping.
+func (f *File) Geometry() (*geo.Geometry, []Batch, error) {
+	if len(f.Faces) == 0 {
+		return nil, nil, errors.New("obj: no faces")
+	}
+
+	var materials []string
+	faces := make(map[string][]Face)
+	for _, face := range f.Faces {
+		if _, ok := faces[face.Material]; !ok {
+			materials = append(materials, face.Material)
+		}
+		faces[face.Material] = append(faces[face.Material], face)
+	}
+
+	smooth := f.smoothNormals()
+	g := &geo.Geometry{}
+	var normals []math.Vec3
+	var batches []Batch
+	for _, material := range materials {
+		b := Batch{Material: material, Start: len(g.Indices), VertStart: g.VertexCount}
+		vertices := make(map[vertexKey]uint32)
+		for _, face := range faces[material] {
+			corners := make([]uint32, len(face.Vertices))
+			for i, v := range face.Vertices {
+				key := vertexKey{v.Position, v.TexCoord, v.Normal}
+				index, ok := vertices[key]
+				if !ok {
+					index = uint32(g.VertexCount)
+					vertices[key] = index
+					g.VertexCount++
+					g.Positions = append(g.Positions, f.Positions[v.Position])
+					var uv math.Vec2
+					if v.TexCoord >= 0 {
+						uv = f.TexCoords[v.TexCoord]
+					}
+					g.TexCoords0 = append(g.TexCoords0, uv)
+					if v.Normal >= 0 {
+						normals = append(normals, f.Normals[v.Normal])
+					} else {
+						normals = append(normals, smooth[v.Position])
+					}
+				}
+				corners[i] = index
+			}
+			for i := 2; i < len(corners); i++ {
+				g.Indices = append(g.Indices, corners[0], corners[i-1], corners[i])
+			}
+		}
+		b.Count = len(g.Indices) - b.Start
+		b.VertEnd = g.VertexCount - 1
+		batches = append(batches, b)
+	}
+
+	g.Normals = make([][3]int16, len(normals))
+	for i, n := range normals {
+		g.Normals[i] = geo.EncodeNormal(n)
+	}
+	g.Streams = []int{geo.StreamPosition, geo.StreamNormal}
+	if len(f.TexCoords) > 0 {
+		tangents, bitangents := tangentSpace(g, normals)
+		g.Tangents = make([][3]int16, len(tangents))
+		g.Bitangents = make([][3]int16, len(bitangents))
+		for i := range tangents {
+			g.Tangents[i] = geo.EncodeNormal(tangents[i])
+			g.Bitangents[i] = geo.EncodeNormal(bitangents[i])
+		}
+		g.Streams = append(g.Streams, geo.StreamTangent, geo.StreamBitangent, geo.StreamTexCoords0)
+	} else {
+		g.TexCoords0 = nil
+	}
+	return g, batches, nil
+}
+
+// smoothNormals averages the normals of the faces around every position, weighted by area
+func (f *File) smoothNormals() []math.Vec3 {
+	normals := make([]math.Vec3, len(f.Positions))
+	for _, face := range f.Faces {
+		p0 := f.Positions[face.Vertices[0].Position]
+		for i := 2; i < len(face.Vertices); i++ {
+			p1 := f.Positions[face.Vertices[i-1].Position]
+			p2 := f.Positions[face.Vertices[i].Position]
+			n := p1.Sub(p0).Cross(p2.Sub(p0))
+			for _, v := range []Vertex{face.Vertices[0], face.Vertices[i-1], face.Vertices[i]} {
+				normals[v.Position] = normals[v.Position].Add(n)
+			}
+		}
+	}
+	for i := range normals {
+		normals[i] = normals[i].Normalize()
+	}
+	return normals
+}
+
+// tangentSpace computes the tangents and bitangents of the vertices from the texture
+// coordinates of their triangles, orthogonal to the normals
+func tangentSpace(g *geo.Geometry, normals []math.Vec3) (tangents []math.Vec3,
+	bitangents []math.Vec3) {
+	tan := make([]math.Vec3, g.VertexCount)
+	bitan := make([]math.Vec3, g.VertexCount)
+	for i := 0; i+2 < len(g.Indices); i += 3 {
+		i0, i1, i2 := g.Indices[i], g.Indices[i+1], g.Indices[i+2]
+		e1 := g.Positions[i1].Sub(g.Positions[i0])
+		e2 := g.Positions[i2].Sub(g.Positions[i0])
+		uv1 := g.TexCoords0[i1].Sub(g.TexCoords0[i0])
+		uv2 := g.TexCoords0[i2].Sub(g.TexCoords0[i0])
+		det := uv1.X*uv2.Y - uv2.X*uv1.Y
+		if det == 0 {
+			continue
+		}
+		t := e1.Mul(uv2.Y).Sub(e2.Mul(uv1.Y)).Mul(1 / det)
+		b := e2.Mul(uv1.X).Sub(e1.Mul(uv2.X)).Mul(1 / det)
+		for _, index := range []uint32{i0, i1, i2} {
+			tan[index] = tan[index].Add(t)
+			bitan[index] = bitan[index].Add(b)
+		}
+	}
+
+	for i, n := range normals {
+		// Gram-Schmidt, falling back to any vector orthogonal to the normal
+		t := tan[i].Sub(n.Mul(n.Dot(tan[i]))).Normalize()
+		if t.Len() == 0 {
+			axis := math.Vec3{X: 1}
+			if n.X > 0.9 || n.X < -0.9 {
+				axis = math.Vec3{Y: 1}
+			}
+			t = axis.Sub(n.Mul(n.Dot(axis))).Normalize()
+		}
+		b := n.Cross(t)
+		if b.Dot(bitan[i]) < 0 {
+			b = b.Mul(-1)
+		}
+		tan[i], bitan[i] = t, b
+	}
+	return tan, bitan
+}
@@ -0,0 +1,309 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+// Package obj reads Wavefront OBJ models and their MTL material libraries and converts them to
+// Horde3D geometry, for importing simple models without going through the Collada converter.
+//
+// Polygons are triangulated as fans, and only the elements needed for static meshes are read:
+// vertices, texture coordinates, normals, faces, groups and materials.  Other elements, like
+// lines or free-form surfaces, are skipped.
+package obj
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"bitbucket.org/tshannon/gohorde/horde3d/math"
+)
+
+var ErrFormat = errors.New("obj: invalid file")
+
+// File is the content of an OBJ file
+type File struct {
+	Positions    []math.Vec3
+	TexCoords    []math.Vec2
+	Normals      []math.Vec3
+	Faces        []Face
+	MaterialLibs []string   // names of the MTL files, relative to the OBJ file
+	Materials    []Material // read from the material libraries by Load
+}
+
+// Face is a polygon of at least three vertices
+type Face struct {
+	Vertices []Vertex
+	Material string // set by the last usemtl
+	Group    string // set by the last g or o
+}
+
+// Vertex indexes the attributes of a face corner, starting at 0.  Missing attributes are -1.
+type Vertex struct {
+	Position int
+	TexCoord int
+	Normal   int
+}
+
+// Material is a material of an MTL file.  Texture maps are relative to the MTL file, or to the
+// file system root if the material was read by Load.
+type Material struct {
+	Name       string
+	Diffuse    math.Vec3 // Kd
+	Specular   math.Vec3 // Ks
+	Shininess  float32   // Ns, the specular exponent
+	Opacity    float32   // d, or 1 - Tr
+	DiffuseMap string    // map_Kd
+	BumpMap    string    // map_Bump or bump, a tangent space normal map
+}
+
+// Decode reads an OBJ file.  The material libraries it references are not read.
+func Decode(r io.Reader) (*File, error) {
+	f := &File{}
+	var material, group string
+	err := scanLines(r, func(keyword string, args []string) error {
+		var err error
+		switch keyword {
+		case "v":
+			var v math.Vec3
+			v, err = parseVec3(args)
+			f.Positions = append(f.Positions, v)
+		case "vt":
+			// v and w are optional
+			uv := []string{"0", "0", "0"}
+			if len(args) == 0 {
+				err = errors.New("missing value")
+				break
+			}
+			copy(uv, args)
+			var v math.Vec3
+			v, err = parseVec3(uv)
+			f.TexCoords = append(f.TexCoords, math.Vec2{X: v.X, Y: v.Y})
+		case "vn":
+			var v math.Vec3
+			v, err = parseVec3(args)
+			f.Normals = append(f.Normals, v.Normalize())
+		case "f":
+			face := Face{Material: material, Group: group}
+			for _, arg := range args {
+				var v Vertex
+				if v, err = f.parseVertex(arg); err != nil {
+					break
+				}
+				face.Vertices = append(face.Vertices, v)
+			}
+			if err == nil && len(face.Vertices) < 3 {
+				err = errors.New("face with less than three vertices")
+			}
+			f.Faces = append(f.Faces, face)
+		case "usemtl":
+			material = strings.Join(args, " ")
+		case "g", "o":
+			group = strings.Join(args, " ")
+		case "mtllib":
+			f.MaterialLibs = append(f.MaterialLibs, args...)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// DecodeMTL reads the materials of an MTL file
+func DecodeMTL(r io.Reader) ([]Material, error) {
+	var materials []Material
+	var m *Material
+	err := scanLines(r, func(keyword string, args []string) error {
+		if keyword == "newmtl" {
+			materials = append(materials, Material{Name: strings.Join(args, " "), Opacity: 1})
+			m = &materials[len(materials)-1]
+			return nil
+		}
+		if m == nil {
+			return nil
+		}
+		var err error
+		switch keyword {
+		case "Kd":
+			m.Diffuse, err = parseVec3(args)
+		case "Ks":
+			m.Specular, err = parseVec3(args)
+		case "Ns":
+			m.Shininess, err = parseFloat(args)
+		case "d":
+			m.Opacity, err = parseFloat(args)
+		case "Tr":
+			var tr float32
+			tr, err = parseFloat(args)
+			m.Opacity = 1 - tr
+		case "map_Kd":
+			m.DiffuseMap = mapName(args)
+		case "map_Bump", "map_bump", "bump":
+			m.BumpMap = mapName(args)
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return materials, nil
+}
+
+// Load reads the OBJ file name from fsys together with its material libraries
+func Load(fsys fs.FS, name string) (*File, error) {
+	r, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	f, err := Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	for _, lib := range f.MaterialLibs {
+		libName := path.Join(path.Dir(name), lib)
+		r, err := fsys.Open(libName)
+		if err != nil {
+			return nil, err
+		}
+		materials, err := DecodeMTL(r)
+		r.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", libName, err)
+		}
+		for _, m := range materials {
+			if m.DiffuseMap != "" {
+				m.DiffuseMap = path.Join(path.Dir(libName), m.DiffuseMap)
+			}
+			if m.BumpMap != "" {
+				m.BumpMap = path.Join(path.Dir(libName), m.BumpMap)
+			}
+			f.Materials = append(f.Materials, m)
+		}
+	}
+	return f, nil
+}
+
+// Material returns the material with the given name, or nil
+func (f *File) Material(name string) *Material {
+	i := slices.IndexFunc(f.Materials, func(m Material) bool { return m.Name == name })
+	if i < 0 {
+		return nil
+	}
+	return &f.Materials[i]
+}
+
+// scanLines calls fn with the keyword and arguments of every line that isn't empty or a comment.
+// Lines ending in a backslash are joined with the next one.
+func scanLines(r io.Reader, fn func(keyword string, args []string) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	line := 0
+	var text string
+	for scanner.Scan() {
+		line++
+		text += scanner.Text()
+		if strings.HasSuffix(text, "\\") {
+			text = text[:len(text)-1] + " "
+			continue
+		}
+		if i := strings.IndexByte(text, '#'); i >= 0 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		text = ""
+		if len(fields) == 0 {
+			continue
+		}
+		if err := fn(fields[0], fields[1:]); err != nil {
+			return fmt.Errorf("%w: line %d: %s: %v", ErrFormat, line, fields[0], err)
+		}
+	}
+	return scanner.Err()
+}
+
+func parseFloat(args []string) (float32, error) {
+	if len(args) < 1 {
+		return 0, errors.New("missing value")
+	}
+	v, err := strconv.ParseFloat(args[0], 32)
+	return float32(v), err
+}
+
+func parseVec3(args []string) (math.Vec3, error) {
+	if len(args) < 3 {
+		return math.Vec3{}, errors.New("expected three values")
+	}
+	var v [3]float32
+	for i := range v {
+		f, err := strconv.ParseFloat(args[i], 32)
+		if err != nil {
+			return math.Vec3{}, err
+		}
+		v[i] = float32(f)
+	}
+	return math.Vec3{X: v[0], Y: v[1], Z: v[2]}, nil
+}
+
+// mapName returns the file name of a texture map statement, skipping its options
+func mapName(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return strings.ReplaceAll(args[len(args)-1], "\\", "/")
+}
+
+// parseVertex parses a v, v/vt, v//vn or v/vt/vn face corner
+func (f *File) parseVertex(s string) (Vertex, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) > 3 {
+		return Vertex{}, fmt.Errorf("invalid vertex %q", s)
+	}
+	v := Vertex{Position: -1, TexCoord: -1, Normal: -1}
+	counts := []int{len(f.Positions), len(f.TexCoords), len(f.Normals)}
+	indices := []*int{&v.Position, &v.TexCoord, &v.Normal}
+	for i, part := range parts {
+		if part == "" && i > 0 {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return Vertex{}, fmt.Errorf("invalid vertex %q", s)
+		}
+		// negative indices count back from the last element
+		if n < 0 {
+			n += counts[i]
+		} else {
+			n--
+		}
+		if n < 0 || n >= counts[i] {
+			return Vertex{}, fmt.Errorf("vertex %q is out of range", s)
+		}
+		*indices[i] = n
+	}
+	return v, nil
+}
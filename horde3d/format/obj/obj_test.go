@@ -0,0 +1,195 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package obj
+
+import (
+	"bytes"
+	"errors"
+	stdmath "math"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"bitbucket.org/tshannon/gohorde/horde3d/format/geo"
+	"bitbucket.org/tshannon/gohorde/horde3d/math"
+)
+
+// cube has two materials, faces with and without normals and texture coordinates, and
+// negative indices
+const cube = `# cube
+mtllib cube.mtl
+v -1 -1 1
+v 1 -1 1
+v 1 1 1
+v -1 1 1
+v -1 -1 -1
+v 1 -1 -1
+v 1 1 -1
+v -1 1 -1
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+vn 0 0 1
+o Cube
+usemtl red
+f 1/1/1 2/2/1 3/3/1 4/4/1
+f 6/1 5/2 8/3 7/4
+usemtl blue
+f 5/1 1/2 4/3 8/4
+f -7/1 -3/2 -2/3 -6/4
+usemtl red
+f 4/1 3/2 7/3 8/4
+f 5/1 6/2 2/3 1/4
+`
+
+const cubeMTL = `newmtl red
+Kd 1 0 0
+Ks 0.5 0.5 0.5
+Ns 32
+map_Kd -s 1 1 1 tex\red.png
+newmtl blue
+Kd 0 0 1
+d 0.5
+bump blue_n.png
+`
+
+func abs(f float32) float32 {
+	return float32(stdmath.Abs(float64(f)))
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"models/cube.obj": {Data: []byte(cube)},
+		"models/cube.mtl": {Data: []byte(cubeMTL)},
+	}
+	f, err := Load(fsys, "models/cube.obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Positions) != 8 || len(f.TexCoords) != 4 || len(f.Normals) != 1 || len(f.Faces) != 6 {
+		t.Fatalf("loaded %d positions, %d texture coordinates, %d normals, %d faces",
+			len(f.Positions), len(f.TexCoords), len(f.Normals), len(f.Faces))
+	}
+	if v := f.Faces[3].Vertices[0]; v != (Vertex{Position: 1, TexCoord: 0, Normal: -1}) {
+		t.Errorf("negative indices resolve to %+v", v)
+	}
+	red, blue := f.Material("red"), f.Material("blue")
+	if red == nil || red.DiffuseMap != "models/tex/red.png" || red.Shininess != 32 ||
+		red.Specular != (math.Vec3{X: 0.5, Y: 0.5, Z: 0.5}) {
+		t.Errorf("red is %+v", red)
+	}
+	if blue == nil || blue.BumpMap != "models/blue_n.png" || blue.Opacity != 0.5 {
+		t.Errorf("blue is %+v", blue)
+	}
+}
+
+func TestGeometry(t *testing.T) {
+	f, err := Decode(strings.NewReader(cube))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, batches, err := f.Geometry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 2 || batches[0].Material != "red" || batches[0].Count != 24 ||
+		batches[1].Material != "blue" || batches[1].Count != 12 ||
+		batches[1].Start != 24 || batches[1].VertStart != batches[0].VertEnd+1 ||
+		batches[1].VertEnd != g.VertexCount-1 {
+		t.Fatalf("batches %+v", batches)
+	}
+	if len(g.Normals) != g.VertexCount || len(g.Tangents) != g.VertexCount ||
+		len(g.Bitangents) != g.VertexCount || len(g.TexCoords0) != g.VertexCount {
+		t.Fatalf("streams of %d vertices: %v", g.VertexCount, g.Streams)
+	}
+
+	for i, p := range g.Positions {
+		n := geo.DecodeNormal(g.Normals[i])
+		tangent := geo.DecodeNormal(g.Tangents[i])
+		bitangent := geo.DecodeNormal(g.Bitangents[i])
+		// the smooth normals of a cube centered on the origin point away from it
+		if n.Dot(p) <= 0 || abs(n.Len()-1) > 1e-3 {
+			t.Errorf("vertex %d at %v has normal %v", i, p, n)
+		}
+		if abs(n.Dot(tangent)) > 1e-3 || abs(n.Dot(bitangent)) > 1e-3 || abs(tangent.Len()-1) > 1e-3 {
+			t.Errorf("vertex %d has tangent space %v %v %v", i, n, tangent, bitangent)
+		}
+	}
+	for i := 0; i < len(g.Indices); i += 3 {
+		a, b, c := g.Positions[g.Indices[i]], g.Positions[g.Indices[i+1]], g.Positions[g.Indices[i+2]]
+		if b.Sub(a).Cross(c.Sub(a)).Dot(geo.DecodeNormal(g.Normals[g.Indices[i]])) <= 0 {
+			t.Errorf("triangle %d is wound against its normal", i/3)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := geo.Encode(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := geo.Decode(&buf); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGeometryNormals(t *testing.T) {
+	// a quad given with v//vn corners, one of which is shared by both triangles
+	f, err := Decode(strings.NewReader("v 0 0 0\nv 1 0 0\nv 1 1 0\nv 0 1 0\nvn 0 0 1\n" +
+		"f 1//1 2//1 3//1\nf 1//1 3//1 4//1\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, batches, err := f.Geometry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.VertexCount != 4 || len(g.Indices) != 6 || len(batches) != 1 || batches[0].Material != "" {
+		t.Fatalf("%d vertices, %d indices, batches %+v", g.VertexCount, len(g.Indices), batches)
+	}
+	if g.Tangents != nil || g.TexCoords0 != nil {
+		t.Error("geometry without texture coordinates has tangents")
+	}
+	for _, n := range g.Normals {
+		if n != geo.EncodeNormal(math.Vec3{Z: 1}) {
+			t.Errorf("normal %v", n)
+		}
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, src := range []string{
+		"vt\n",
+		"v 1 2\n",
+		"v 1 2 x\n",
+		"v 1 2 3\nf 1 2 3\n",
+		"v 1 2 3\nv 1 2 3\nf 1 2\n",
+		"v 1 2 3\nf 1/1 1/1 1/1\n",
+		"v 1 2 3\nf 0 1 1\n",
+		"v 1 2 3\nf 1/1/1/1 1 1\n",
+	} {
+		if _, err := Decode(strings.NewReader(src)); !errors.Is(err, ErrFormat) {
+			t.Errorf("%q decodes with %v", src, err)
+		}
+	}
+	if _, _, err := (&File{}).Geometry(); err == nil {
+		t.Error("empty file converts")
+	}
+}
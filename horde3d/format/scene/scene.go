@@ -0,0 +1,347 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+// Package scene is a data model of Horde3D scene graph files (.scene.xml), for tools that
+// generate, rewrite or check scene graphs.  Every node element the engine accepts has a type
+// with its attributes as fields, attributes that aren't known are kept as they are.
+package scene
+
+import (
+	"encoding/xml"
+	stdmath "math"
+
+	"bitbucket.org/tshannon/gohorde/horde3d/math"
+)
+
+// Node is one of the node types of this package
+type Node interface {
+	// Base returns the attributes and children common to all nodes
+	Base() *NodeBase
+	// Element returns the XML element name of the node
+	Element() string
+
+	attrs() []attr
+}
+
+// NodeBase holds what all nodes have in common
+type NodeBase struct {
+	Name        string
+	Translation math.Vec3
+	Rotation    math.Vec3 // Euler angles in degrees
+	Scale       math.Vec3
+	Children    []Node
+
+	// Extra holds the attributes not known for the node type, in the order they were read
+	Extra []xml.Attr
+}
+
+func (b *NodeBase) Base() *NodeBase {
+	return b
+}
+
+func (b *NodeBase) attrs() []attr {
+	return b.withAttrs()
+}
+
+// withAttrs returns the attributes of a node type, with its own attributes between the name and
+// the transformation
+func (b *NodeBase) withAttrs(attrs ...attr) []attr {
+	all := []attr{{name: "name", value: &b.Name, def: ""}}
+	all = append(all, attrs...)
+	return append(all,
+		attr{name: "tx", value: &b.Translation.X, def: float32(0)},
+		attr{name: "ty", value: &b.Translation.Y, def: float32(0)},
+		attr{name: "tz", value: &b.Translation.Z, def: float32(0)},
+		attr{name: "rx", value: &b.Rotation.X, def: float32(0)},
+		attr{name: "ry", value: &b.Rotation.Y, def: float32(0)},
+		attr{name: "rz", value: &b.Rotation.Z, def: float32(0)},
+		attr{name: "sx", value: &b.Scale.X, def: float32(1)},
+		attr{name: "sy", value: &b.Scale.Y, def: float32(1)},
+		attr{name: "sz", value: &b.Scale.Z, def: float32(1)},
+	)
+}
+
+// Group only groups its children
+type Group struct {
+	NodeBase
+}
+
+func NewGroup(name string) *Group {
+	return withDefaults(&Group{NodeBase: NodeBase{Name: name}})
+}
+
+func (*Group) Element() string {
+	return "Group"
+}
+
+// Model ties a geometry to the Mesh and Joint nodes below it
+type Model struct {
+	NodeBase
+	Geometry         string
+	SoftwareSkinning bool
+	LODDist          [4]float32 // distances at which LOD levels 1 to 4 start
+}
+
+func NewModel(name string, geometry string) *Model {
+	return withDefaults(&Model{NodeBase: NodeBase{Name: name}, Geometry: geometry})
+}
+
+func (*Model) Element() string {
+	return "Model"
+}
+
+func (m *Model) attrs() []attr {
+	return m.withAttrs(
+		attr{name: "geometry", value: &m.Geometry, required: true},
+		attr{name: "softwareSkinning", value: &m.SoftwareSkinning, def: false},
+		attr{name: "lodDist1", value: &m.LODDist[0], def: float32(stdmath.MaxFloat32)},
+		attr{name: "lodDist2", value: &m.LODDist[1], def: float32(stdmath.MaxFloat32)},
+		attr{name: "lodDist3", value: &m.LODDist[2], def: float32(stdmath.MaxFloat32)},
+		attr{name: "lodDist4", value: &m.LODDist[3], def: float32(stdmath.MaxFloat32)},
+	)
+}
+
+// Mesh renders a range of the triangles of the geometry of its Model
+type Mesh struct {
+	NodeBase
+	Material   string
+	BatchStart int // first index
+	BatchCount int // number of indices
+	VertRStart int // first vertex used
+	VertREnd   int // last vertex used
+	LODLevel   int
+}
+
+func NewMesh(name string, material string) *Mesh {
+	return withDefaults(&Mesh{NodeBase: NodeBase{Name: name}, Material: material})
+}
+
+func (*Mesh) Element() string {
+	return "Mesh"
+}
+
+func (m *Mesh) attrs() []attr {
+	return m.withAttrs(
+		attr{name: "material", value: &m.Material, required: true},
+		attr{name: "batchStart", value: &m.BatchStart, required: true},
+		attr{name: "batchCount", value: &m.BatchCount, required: true},
+		attr{name: "vertRStart", value: &m.VertRStart, required: true},
+		attr{name: "vertREnd", value: &m.VertREnd, required: true},
+		attr{name: "lodLevel", value: &m.LODLevel, def: 0},
+	)
+}
+
+// Joint is a bone of the skeleton of its Model
+type Joint struct {
+	NodeBase
+	JointIndex int
+}
+
+func NewJoint(name string, jointIndex int) *Joint {
+	return withDefaults(&Joint{NodeBase: NodeBase{Name: name}, JointIndex: jointIndex})
+}
+
+func (*Joint) Element() string {
+	return "Joint"
+}
+
+func (j *Joint) attrs() []attr {
+	return j.withAttrs(
+		attr{name: "jointIndex", value: &j.JointIndex, required: true},
+	)
+}
+
+// Light is a spot light, shining down its negative z axis
+type Light struct {
+	NodeBase
+	Material          string
+	Radius            float32
+	Fov               float32
+	Color             math.Vec3
+	ColorMultiplier   float32
+	ShadowMapCount    int
+	ShadowSplitLambda float32
+	ShadowMapBias     float32
+	LightingContext   string
+	ShadowContext     string
+}
+
+func NewLight(name string, material string) *Light {
+	return withDefaults(&Light{NodeBase: NodeBase{Name: name}, Material: material})
+}
+
+func (*Light) Element() string {
+	return "Light"
+}
+
+func (l *Light) attrs() []attr {
+	return l.withAttrs(
+		attr{name: "material", value: &l.Material, def: ""},
+		attr{name: "radius", value: &l.Radius, def: float32(100)},
+		attr{name: "fov", value: &l.Fov, def: float32(90)},
+		attr{name: "col_R", value: &l.Color.X, def: float32(1)},
+		attr{name: "col_G", value: &l.Color.Y, def: float32(1)},
+		attr{name: "col_B", value: &l.Color.Z, def: float32(1)},
+		attr{name: "colMult", value: &l.ColorMultiplier, def: float32(1)},
+		attr{name: "shadowMapCount", value: &l.ShadowMapCount, def: 0},
+		attr{name: "shadowSplitLambda", value: &l.ShadowSplitLambda, def: float32(0.5)},
+		attr{name: "shadowMapBias", value: &l.ShadowMapBias, def: float32(0.005)},
+		attr{name: "lightingContext", value: &l.LightingContext, def: ""},
+		attr{name: "shadowContext", value: &l.ShadowContext, def: ""},
+	)
+}
+
+// Camera renders the scene with a pipeline, looking down its negative z axis
+type Camera struct {
+	NodeBase
+	Pipeline          string
+	OutputTex         string
+	OutputBufferIndex int
+	LeftPlane         float32
+	RightPlane        float32
+	BottomPlane       float32
+	TopPlane          float32
+	NearPlane         float32
+	FarPlane          float32
+	Orthographic      bool
+	OcclusionCulling  bool
+}
+
+func NewCamera(name string, pipeline string) *Camera {
+	return withDefaults(&Camera{NodeBase: NodeBase{Name: name}, Pipeline: pipeline})
+}
+
+func (*Camera) Element() string {
+	return "Camera"
+}
+
+func (c *Camera) attrs() []attr {
+	// the default frustum has a vertical field of view of 45 degrees and an aspect of 4:3
+	return c.withAttrs(
+		attr{name: "pipeline", value: &c.Pipeline, required: true},
+		attr{name: "outputTex", value: &c.OutputTex, def: ""},
+		attr{name: "outputBufferIndex", value: &c.OutputBufferIndex, def: 0},
+		attr{name: "leftPlane", value: &c.LeftPlane, def: float32(-0.055228457)},
+		attr{name: "rightPlane", value: &c.RightPlane, def: float32(0.055228457)},
+		attr{name: "bottomPlane", value: &c.BottomPlane, def: float32(-0.041421354)},
+		attr{name: "topPlane", value: &c.TopPlane, def: float32(0.041421354)},
+		attr{name: "nearPlane", value: &c.NearPlane, def: float32(0.1)},
+		attr{name: "farPlane", value: &c.FarPlane, def: float32(1000)},
+		attr{name: "orthographic", value: &c.Orthographic, def: false},
+		attr{name: "occlusionCulling", value: &c.OcclusionCulling, def: false},
+	)
+}
+
+// Emitter emits the particles of a particle effect
+type Emitter struct {
+	NodeBase
+	Material       string
+	ParticleEffect string
+	MaxCount       int
+	RespawnCount   int // -1 respawns forever
+	Delay          float32
+	EmissionRate   float32
+	SpreadAngle    float32
+	Force          math.Vec3
+}
+
+func NewEmitter(name string, material string, particleEffect string, maxCount int) *Emitter {
+	return withDefaults(&Emitter{NodeBase: NodeBase{Name: name}, Material: material,
+		ParticleEffect: particleEffect, MaxCount: maxCount})
+}
+
+func (*Emitter) Element() string {
+	return "Emitter"
+}
+
+func (e *Emitter) attrs() []attr {
+	return e.withAttrs(
+		attr{name: "material", value: &e.Material, required: true},
+		attr{name: "particleEffect", value: &e.ParticleEffect, required: true},
+		attr{name: "maxCount", value: &e.MaxCount, required: true},
+		attr{name: "respawnCount", value: &e.RespawnCount, def: -1},
+		attr{name: "delay", value: &e.Delay, def: float32(0)},
+		attr{name: "emissionRate", value: &e.EmissionRate, def: float32(0)},
+		attr{name: "spreadAngle", value: &e.SpreadAngle, def: float32(0)},
+		attr{name: "forceX", value: &e.Force.X, def: float32(0)},
+		attr{name: "forceY", value: &e.Force.Y, def: float32(0)},
+		attr{name: "forceZ", value: &e.Force.Z, def: float32(0)},
+	)
+}
+
+// Reference adds the nodes of another scene graph file in its place
+type Reference struct {
+	NodeBase
+	SceneGraph string
+}
+
+func NewReference(name string, sceneGraph string) *Reference {
+	return withDefaults(&Reference{NodeBase: NodeBase{Name: name}, SceneGraph: sceneGraph})
+}
+
+func (*Reference) Element() string {
+	return "Reference"
+}
+
+func (r *Reference) attrs() []attr {
+	return r.withAttrs(
+		attr{name: "sceneGraph", value: &r.SceneGraph, required: true},
+	)
+}
+
+func newNode(element string) Node {
+	switch element {
+	case "Group":
+		return &Group{}
+	case "Model":
+		return &Model{}
+	case "Mesh":
+		return &Mesh{}
+	case "Joint":
+		return &Joint{}
+	case "Light":
+		return &Light{}
+	case "Camera":
+		return &Camera{}
+	case "Emitter":
+		return &Emitter{}
+	case "Reference":
+		return &Reference{}
+	}
+	return nil
+}
+
+// Walk calls fn for node and all nodes below it, depth first, with the path of names leading to
+// each node
+func Walk(node Node, fn func(path string, node Node) error) error {
+	return walk(node.Base().Name, node, fn)
+}
+
+func walk(path string, node Node, fn func(string, Node) error) error {
+	if err := fn(path, node); err != nil {
+		return err
+	}
+	for _, child := range node.Base().Children {
+		if err := walk(path+"/"+child.Base().Name, child, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
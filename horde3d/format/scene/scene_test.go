@@ -0,0 +1,132 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package scene
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+const contentDir = "../../../examples/content"
+
+var exampleScenes = []string{
+	"models/knight/knight.scene.xml",
+	"models/platform/platform.scene.xml",
+	"models/skybox/skybox.scene.xml",
+	"models/sphere/sphere.scene.xml",
+	"particles/particleSys1/particleSys1.scene.xml",
+}
+
+func encode(t *testing.T, root Node) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Encode(&buf, root); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestExampleScenes(t *testing.T) {
+	fsys := os.DirFS(contentDir)
+	for _, name := range exampleScenes {
+		data, err := os.ReadFile(contentDir + "/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := Validate(root, fsys); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+
+		encoded := encode(t, root)
+		again, err := Decode(strings.NewReader(encoded))
+		if err != nil {
+			t.Fatalf("%s: decoding the encoded scene: %v", name, err)
+		}
+		if encode(t, again) != encoded {
+			t.Errorf("%s: encoding isn't stable:\n%s", name, encoded)
+		}
+		if err := Validate(again, fsys); err != nil {
+			t.Errorf("%s: encoded scene: %v", name, err)
+		}
+	}
+}
+
+func TestConstructors(t *testing.T) {
+	light := NewLight("sun", "materials/light.material.xml")
+	if light.Name != "sun" || light.Material != "materials/light.material.xml" ||
+		light.Radius != 100 || light.Scale.X != 1 {
+		t.Errorf("NewLight = %+v", light)
+	}
+	mesh := NewMesh("body", "models/knight/knight.material.xml")
+	if mesh.Name != "body" || mesh.Material != "models/knight/knight.material.xml" {
+		t.Errorf("NewMesh = %+v", mesh)
+	}
+	model := NewModel("knight", "models/knight/knight.geo")
+	model.Children = append(model.Children, mesh)
+	want := `<Model name="knight" geometry="models/knight/knight.geo">`
+	if got := encode(t, model); !strings.HasPrefix(got, want) {
+		t.Errorf("encoded model:\n%s", got)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	root, err := Decode(strings.NewReader(`<Group name="g">
+	<Mesh name="orphan" material="x" batchStart="0" batchCount="3" vertRStart="5" vertREnd="2" />
+	<Model name="sky" geometry="models/skybox/skybox.geo">
+		<Joint name="j" jointIndex="3" />
+		<Mesh name="m" material="x" batchStart="30" batchCount="36" vertRStart="0" vertREnd="23" />
+	</Model>
+	<Light name="l" fov="200" />
+</Group>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Validate(root, os.DirFS(contentDir))
+	for _, path := range []string{"g/orphan", "g/sky/j", "g/sky/m", "g/l"} {
+		if !strings.Contains(err.Error(), path+":") {
+			t.Errorf("no error for %s in:\n%v", path, err)
+		}
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Errorf("%T is not a *ValidationError", err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	for _, src := range []string{
+		`<Model name="x" />`,
+		`<Unknown name="x" />`,
+		`<Group name="x" tx="abc" />`,
+		`<Group name="x">`,
+	} {
+		if _, err := Decode(strings.NewReader(src)); err == nil {
+			t.Errorf("%s decodes", src)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package scene
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"bitbucket.org/tshannon/gohorde/horde3d/format/geo"
+)
+
+// ValidationError reports a problem with the node at Path, the names of the nodes leading to it
+// separated by slashes
+type ValidationError struct {
+	Path string
+	Msg  string
+}
+
+func (e *ValidationError) Error() string {
+	return "scene: " + e.Path + ": " + e.Msg
+}
+
+// Validate checks the structure of the scene graph below root and the values the engine would
+// reject.  If fsys is not nil, the geometry of every Model is read from it to check the batch
+// and vertex ranges of its meshes and the indices of its joints.
+func Validate(root Node, fsys fs.FS) error {
+	v := &validator{fsys: fsys, geometry: make(map[string]*geo.Geometry)}
+	v.node(root.Base().Name, root, nil)
+	return errors.Join(v.errs...)
+}
+
+type validator struct {
+	fsys     fs.FS
+	geometry map[string]*geo.Geometry
+	errs     []error
+}
+
+func (v *validator) fail(path string, format string, args ...any) {
+	v.errs = append(v.errs, &ValidationError{Path: path, Msg: fmt.Sprintf(format, args...)})
+}
+
+// node checks n, model is the closest Model above it
+func (v *validator) node(p string, n Node, model *Model) {
+	switch n := n.(type) {
+	case *Model:
+		if n.Geometry == "" {
+			v.fail(p, "model without geometry")
+		}
+		model = n
+	case *Mesh:
+		v.mesh(p, n, model)
+	case *Joint:
+		v.joint(p, n, model)
+	case *Light:
+		if n.Radius <= 0 {
+			v.fail(p, "radius %g is not positive", n.Radius)
+		}
+		if n.Fov <= 0 || n.Fov >= 180 {
+			v.fail(p, "fov %g is not between 0 and 180 degrees", n.Fov)
+		}
+		if n.ShadowMapCount < 0 || n.ShadowMapCount > 4 {
+			v.fail(p, "shadowMapCount %d is not between 0 and 4", n.ShadowMapCount)
+		}
+	case *Camera:
+		if n.Pipeline == "" {
+			v.fail(p, "camera without pipeline")
+		}
+		if n.NearPlane >= n.FarPlane {
+			v.fail(p, "nearPlane %g is not in front of farPlane %g", n.NearPlane, n.FarPlane)
+		}
+		if !n.Orthographic && n.NearPlane <= 0 {
+			v.fail(p, "nearPlane %g of a perspective camera is not positive", n.NearPlane)
+		}
+	case *Emitter:
+		if n.Material == "" || n.ParticleEffect == "" {
+			v.fail(p, "emitter without material or particle effect")
+		}
+		if n.MaxCount <= 0 {
+			v.fail(p, "maxCount %d is not positive", n.MaxCount)
+		}
+	case *Reference:
+		if n.SceneGraph == "" {
+			v.fail(p, "reference without scene graph")
+		}
+	}
+
+	for _, child := range n.Base().Children {
+		v.node(p+"/"+child.Base().Name, child, model)
+	}
+}
+
+func (v *validator) mesh(p string, m *Mesh, model *Model) {
+	if model == nil {
+		v.fail(p, "mesh is not below a model")
+	}
+	if m.Material == "" {
+		v.fail(p, "mesh without material")
+	}
+	if m.BatchStart < 0 || m.BatchCount <= 0 {
+		v.fail(p, "invalid batch %d+%d", m.BatchStart, m.BatchCount)
+	}
+	if m.VertRStart < 0 || m.VertRStart > m.VertREnd {
+		v.fail(p, "invalid vertex range %d-%d", m.VertRStart, m.VertREnd)
+	}
+
+	g := v.geo(p, model)
+	if g == nil {
+		return
+	}
+	if m.BatchStart+m.BatchCount > len(g.Indices) {
+		v.fail(p, "batch %d+%d is outside the %d indices of %s", m.BatchStart, m.BatchCount,
+			len(g.Indices), model.Geometry)
+	}
+	if m.VertREnd >= g.VertexCount {
+		v.fail(p, "vertex range %d-%d is outside the %d vertices of %s", m.VertRStart, m.VertREnd,
+			g.VertexCount, model.Geometry)
+	}
+}
+
+func (v *validator) joint(p string, j *Joint, model *Model) {
+	if model == nil {
+		v.fail(p, "joint is not below a model")
+	}
+	if j.JointIndex < 0 {
+		v.fail(p, "negative jointIndex %d", j.JointIndex)
+	}
+
+	g := v.geo(p, model)
+	if g != nil && j.JointIndex >= len(g.Joints) {
+		v.fail(p, "jointIndex %d is outside the %d joints of %s", j.JointIndex, len(g.Joints),
+			model.Geometry)
+	}
+}
+
+// geo returns the geometry of model, reading it the first time it is needed
+func (v *validator) geo(p string, model *Model) *geo.Geometry {
+	if v.fsys == nil || model == nil || model.Geometry == "" {
+		return nil
+	}
+	if g, ok := v.geometry[model.Geometry]; ok {
+		return g
+	}
+
+	v.geometry[model.Geometry] = nil
+	f, err := v.fsys.Open(path.Clean(strings.TrimLeft(model.Geometry, "/")))
+	if err != nil {
+		v.fail(p, "%v", err)
+		return nil
+	}
+	defer f.Close()
+	g, err := geo.Decode(f)
+	if err != nil {
+		v.fail(p, "%s: %v", model.Geometry, err)
+		return nil
+	}
+	v.geometry[model.Geometry] = g
+	return g
+}
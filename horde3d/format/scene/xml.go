@@ -0,0 +1,224 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package scene
+
+import (
+	"bufio"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var ErrFormat = errors.New("scene: invalid scene graph")
+
+// attr describes an attribute of a node type.  value points to the field, which is a *string,
+// *float32, *int or *bool, and def is the value the engine uses when the attribute is missing.
+// Required attributes have no default and are always written.
+type attr struct {
+	name     string
+	value    any
+	def      any
+	required bool
+}
+
+// withDefaults sets the attributes of n that are still zero to their defaults, leaving the
+// values set by the constructors
+func withDefaults[N Node](n N) N {
+	for _, a := range n.attrs() {
+		if a.required {
+			continue
+		}
+		switch v := a.value.(type) {
+		case *string:
+			if *v == "" {
+				*v = a.def.(string)
+			}
+		case *float32:
+			if *v == 0 {
+				*v = a.def.(float32)
+			}
+		case *int:
+			if *v == 0 {
+				*v = a.def.(int)
+			}
+		case *bool:
+			if !*v {
+				*v = a.def.(bool)
+			}
+		}
+	}
+	return n
+}
+
+func (a attr) parse(s string) error {
+	var err error
+	switch v := a.value.(type) {
+	case *string:
+		*v = s
+	case *float32:
+		var f float64
+		f, err = strconv.ParseFloat(strings.TrimSpace(s), 32)
+		*v = float32(f)
+	case *int:
+		*v, err = strconv.Atoi(strings.TrimSpace(s))
+	case *bool:
+		switch strings.ToLower(strings.TrimSpace(s)) {
+		case "true", "1":
+			*v = true
+		case "false", "0":
+			*v = false
+		default:
+			err = fmt.Errorf("%q is not a boolean", s)
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%w: attribute %s: %v", ErrFormat, a.name, err)
+	}
+	return nil
+}
+
+// format returns the attribute value and whether it should be written
+func (a attr) format() (string, bool) {
+	switch v := a.value.(type) {
+	case *string:
+		return *v, a.required || *v != a.def
+	case *float32:
+		return strconv.FormatFloat(float64(*v), 'g', -1, 32), a.required || *v != a.def
+	case *int:
+		return strconv.Itoa(*v), a.required || *v != a.def
+	case *bool:
+		return strconv.FormatBool(*v), a.required || *v != a.def
+	}
+	return "", false
+}
+
+// Decode reads a scene graph file and returns its root node
+func Decode(r io.Reader) (Node, error) {
+	d := xml.NewDecoder(r)
+	var root Node
+	var stack []Node
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrFormat, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := newNode(t.Name.Local)
+			if n == nil {
+				return nil, fmt.Errorf("%w: unknown element %s", ErrFormat, t.Name.Local)
+			}
+			if err := decodeAttrs(n, t.Attr); err != nil {
+				return nil, fmt.Errorf("%s %q: %w", t.Name.Local, n.Base().Name, err)
+			}
+			switch {
+			case len(stack) > 0:
+				parent := stack[len(stack)-1].Base()
+				parent.Children = append(parent.Children, n)
+			case root != nil:
+				return nil, fmt.Errorf("%w: more than one root node", ErrFormat)
+			default:
+				root = n
+			}
+			stack = append(stack, n)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		}
+	}
+	if root == nil {
+		return nil, fmt.Errorf("%w: no root node", ErrFormat)
+	}
+	return root, nil
+}
+
+func decodeAttrs(n Node, attrs []xml.Attr) error {
+	withDefaults(n)
+	known := n.attrs()
+	seen := make(map[string]bool)
+next:
+	for _, xa := range attrs {
+		for _, a := range known {
+			if a.name == xa.Name.Local && xa.Name.Space == "" {
+				if err := a.parse(xa.Value); err != nil {
+					return err
+				}
+				seen[a.name] = true
+				continue next
+			}
+		}
+		n.Base().Extra = append(n.Base().Extra, xa)
+	}
+	for _, a := range known {
+		if a.required && !seen[a.name] {
+			return fmt.Errorf("%w: missing attribute %s", ErrFormat, a.name)
+		}
+	}
+	return nil
+}
+
+// Encode writes the scene graph below root, indented with tabs.  Attributes are only written
+// when they differ from the engine's default.
+func Encode(w io.Writer, root Node) error {
+	bw := bufio.NewWriter(w)
+	encodeNode(bw, root, 0)
+	return bw.Flush()
+}
+
+func encodeNode(w *bufio.Writer, n Node, depth int) {
+	indent := strings.Repeat("\t", depth)
+	w.WriteString(indent + "<" + n.Element())
+	for _, a := range n.attrs() {
+		if value, ok := a.format(); ok {
+			writeAttr(w, a.name, value)
+		}
+	}
+	for _, xa := range n.Base().Extra {
+		name := xa.Name.Local
+		if xa.Name.Space != "" {
+			name = xa.Name.Space + ":" + name
+		}
+		writeAttr(w, name, xa.Value)
+	}
+
+	children := n.Base().Children
+	if len(children) == 0 {
+		w.WriteString(" />\n")
+		return
+	}
+	w.WriteString(">\n")
+	for _, child := range children {
+		encodeNode(w, child, depth+1)
+	}
+	w.WriteString(indent + "</" + n.Element() + ">\n")
+}
+
+func writeAttr(w *bufio.Writer, name string, value string) {
+	w.WriteString(" " + name + "=\"")
+	xml.EscapeText(w, []byte(value))
+	w.WriteString("\"")
+}
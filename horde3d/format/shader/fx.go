@@ -0,0 +1,297 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package shader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokString
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	line int
+}
+
+// lexFX splits the FX section into tokens, dropping comments.  line is the line the section
+// starts at.
+func lexFX(src string, line int) ([]token, error) {
+	var toks []token
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			i++
+		case strings.HasPrefix(src[i:], "//"):
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(src[i:], "/*"):
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				return nil, &Error{Line: line, Msg: "unterminated comment"}
+			}
+			line += strings.Count(src[i:i+2+end], "\n")
+			i += end + 4
+		case c == '"':
+			end := strings.IndexAny(src[i+1:], "\"\n")
+			if end < 0 || src[i+1+end] != '"' {
+				return nil, &Error{Line: line, Msg: "unterminated string"}
+			}
+			toks = append(toks, token{tokString, src[i+1 : i+1+end], line})
+			i += end + 2
+		case isIdentChar(c) && !isDigit(c):
+			j := i
+			for j < len(src) && isIdentChar(src[j]) {
+				j++
+			}
+			toks = append(toks, token{tokIdent, src[i:j], line})
+			i = j
+		case isDigit(c) || c == '-' || c == '+' || c == '.':
+			j := i + 1
+			for j < len(src) && (isDigit(src[j]) || strings.IndexByte(".eE+-f", src[j]) >= 0) {
+				j++
+			}
+			toks = append(toks, token{tokNumber, src[i:j], line})
+			i = j
+		default:
+			toks = append(toks, token{tokPunct, string(c), line})
+			i++
+		}
+	}
+	return append(toks, token{tokEOF, "", line}), nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isIdentChar(c byte) bool {
+	return c == '_' || isDigit(c) || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+type fxParser struct {
+	file string
+	toks []token
+	pos  int
+}
+
+func (p *fxParser) peek() token {
+	return p.toks[p.pos]
+}
+
+func (p *fxParser) next() token {
+	t := p.toks[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *fxParser) errorf(t token, format string, args ...any) error {
+	return &Error{File: p.file, Line: t.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *fxParser) expect(text string) error {
+	if t := p.next(); t.text != text || t.kind == tokString {
+		return p.errorf(t, "expected %q, found %q", text, t.text)
+	}
+	return nil
+}
+
+func (p *fxParser) ident() (token, error) {
+	t := p.next()
+	if t.kind != tokIdent {
+		return t, p.errorf(t, "expected a name, found %q", t.text)
+	}
+	return t, nil
+}
+
+func (e *Effect) parseFX(file string, line int, src string) error {
+	toks, err := lexFX(src, line)
+	if err != nil {
+		err.(*Error).File = file
+		return err
+	}
+	p := &fxParser{file: file, toks: toks}
+	for p.peek().kind != tokEOF {
+		t, err := p.ident()
+		if err != nil {
+			return err
+		}
+		switch t.text {
+		case "sampler2D", "sampler3D", "samplerCube":
+			err = e.parseSampler(p, t)
+		case "float", "float4":
+			err = e.parseUniform(p, t)
+		case "context":
+			err = e.parseContext(p, t)
+		default:
+			err = p.errorf(t, "unknown declaration %q", t.text)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Effect) parseSampler(p *fxParser, typ token) error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	s := Sampler{Name: name.text, Type: typ.text, Line: typ.line}
+	if p.peek().text == "=" {
+		p.next()
+		if err := p.expect("sampler_state"); err != nil {
+			return err
+		}
+		if s.States, err = p.parseStates(); err != nil {
+			return err
+		}
+		if tex, ok := s.States["Texture"]; ok {
+			s.Texture = tex
+			delete(s.States, "Texture")
+		}
+	}
+	e.Samplers = append(e.Samplers, s)
+	return p.expect(";")
+}
+
+func (e *Effect) parseUniform(p *fxParser, typ token) error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	u := Uniform{Name: name.text, Type: typ.text, Line: typ.line}
+	if p.peek().text == "<" {
+		// annotations, only used by editors
+		for t := p.next(); t.text != ">" || t.kind == tokString; t = p.next() {
+			if t.kind == tokEOF {
+				return p.errorf(t, "unterminated annotations of %s", u.Name)
+			}
+		}
+	}
+	if p.peek().text == "=" {
+		p.next()
+		count := 1
+		if typ.text == "float4" {
+			count = 4
+		}
+		braced := p.peek().text == "{"
+		if braced {
+			p.next()
+		}
+		for i := 0; ; i++ {
+			t := p.next()
+			v, err := strconv.ParseFloat(strings.TrimSuffix(t.text, "f"), 32)
+			if t.kind != tokNumber || err != nil {
+				return p.errorf(t, "invalid default value %q of %s", t.text, u.Name)
+			}
+			if i >= count {
+				return p.errorf(t, "%s has more than %d default values", u.Name, count)
+			}
+			u.Default[i] = float32(v)
+			if !braced || p.peek().text != "," {
+				break
+			}
+			p.next()
+		}
+		if braced {
+			if err := p.expect("}"); err != nil {
+				return err
+			}
+		}
+	}
+	e.Uniforms = append(e.Uniforms, u)
+	return p.expect(";")
+}
+
+func (e *Effect) parseContext(p *fxParser, t token) error {
+	name, err := p.ident()
+	if err != nil {
+		return err
+	}
+	c := Context{Name: name.text, Line: t.line}
+	if c.States, err = p.parseStates(); err != nil {
+		return err
+	}
+	for _, key := range []string{"VertexShader", "PixelShader"} {
+		value, ok := c.States[key]
+		if !ok {
+			continue
+		}
+		section, ok := strings.CutPrefix(value, "compile GLSL ")
+		if !ok {
+			return p.errorf(t, "%s of context %s is not of the form compile GLSL NAME", key, c.Name)
+		}
+		if key == "VertexShader" {
+			c.VertexShader = section
+		} else {
+			c.PixelShader = section
+		}
+		delete(c.States, key)
+	}
+	e.Contexts = append(e.Contexts, c)
+	return nil
+}
+
+// parseStates reads a block of key = value; assignments
+func (p *fxParser) parseStates() (map[string]string, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	states := make(map[string]string)
+	for p.peek().text != "}" {
+		key, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		var value []string
+		for t := p.next(); t.text != ";" || t.kind == tokString; t = p.next() {
+			if t.kind == tokEOF || t.text == "}" && t.kind == tokPunct {
+				return nil, p.errorf(t, "missing ; after %s", key.text)
+			}
+			value = append(value, t.text)
+		}
+		states[key.text] = strings.Join(value, " ")
+	}
+	p.next()
+	return states, nil
+}
@@ -0,0 +1,343 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+// Package shader parses Horde3D shader files (.shader), for tools that check shaders and the
+// materials using them without needing the engine.
+//
+// A shader file starts with an [[FX]] section declaring the samplers, uniforms and contexts of
+// the shader, followed by GLSL code sections like [[VS_GENERAL]] or [[FS_LIGHTING]] that the
+// contexts compile.  Code sections can #include code resources, and are specialized by shader
+// flags of the form _F01_Skinning which materials switch on by bit.
+//
+// An Effect implements the ShaderInfo interface of the horde3d package, so a material
+// description can be validated against a parsed shader.
+package shader
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Effect is the content of a shader file
+type Effect struct {
+	Name     string // resource name, if loaded with Load
+	Samplers []Sampler
+	Uniforms []Uniform
+	Contexts []Context
+	Code     []Code
+	Flags    []Flag // in the order they first appear, in the FX section or the code
+}
+
+// Sampler is a texture sampler declared in the FX section
+type Sampler struct {
+	Name    string
+	Type    string // sampler2D, sampler3D or samplerCube
+	Texture string // name of the default texture resource
+	States  map[string]string
+	Line    int
+}
+
+// Uniform is a float or float4 uniform declared in the FX section
+type Uniform struct {
+	Name    string
+	Type    string // float or float4
+	Default [4]float32
+	Line    int
+}
+
+// Context is a render context combining a vertex and a fragment code section
+type Context struct {
+	Name         string
+	VertexShader string // name of the code section, without the brackets
+	PixelShader  string
+	States       map[string]string // render states like ZWriteEnable or BlendMode
+	Line         int
+}
+
+// Code is a GLSL code section.  Expanded holds the code with its includes replaced, once they
+// have been resolved by Load.
+type Code struct {
+	Name     string
+	Source   string
+	Includes []string // names of the code resources included directly
+	Expanded string
+	Line     int // line of the [[NAME]] header
+}
+
+// Flag is a shader flag used in the shader
+type Flag struct {
+	Name string // like _F01_Skinning
+	Bit  int    // number of the flag, 1 to 32 for valid flags
+}
+
+// Error reports a problem at a line of a shader file, or of an included file
+type Error struct {
+	File string
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	s := "shader: "
+	switch {
+	case e.File != "" && e.Line > 0:
+		s += e.File + ":" + strconv.Itoa(e.Line) + ": "
+	case e.File != "":
+		s += e.File + ": "
+	case e.Line > 0:
+		s += "line " + strconv.Itoa(e.Line) + ": "
+	}
+	return s + e.Msg
+}
+
+var (
+	sectionHeader = regexp.MustCompile(`^\[\[(\w+)\]\]\s*$`)
+	includeLine   = regexp.MustCompile(`^\s*#\s*include\s+"([^"]*)"`)
+	flagName      = regexp.MustCompile(`\b_F(\d+)_\w+`)
+)
+
+// Parse reads a shader file.  Includes are listed but not resolved.
+func Parse(r io.Reader) (*Effect, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return parse("", data)
+}
+
+// Load reads the shader file name from fsys and resolves the includes of its code sections,
+// recursively.  Names are resource names, as for the engine.  If fsys has an OpenResource
+// method, like the VFS of the horde3d package, the shader and its includes are opened through
+// it, so the resource paths set for shaders and code apply.
+func Load(fsys fs.FS, name string) (*Effect, error) {
+	data, err := readResource(fsys, resTypeShader, name)
+	if err != nil {
+		return nil, err
+	}
+	e, err := parse(name, data)
+	if err != nil {
+		return nil, err
+	}
+	l := &includer{fsys: fsys, files: make(map[string]string)}
+	for i := range e.Code {
+		c := &e.Code[i]
+		expanded, err := l.expand(name, c.Line, c.Source, nil)
+		if err != nil {
+			return nil, err
+		}
+		c.Expanded = expanded
+		e.addFlags(expanded)
+	}
+	return e, nil
+}
+
+func parse(file string, data []byte) (*Effect, error) {
+	e := &Effect{Name: file}
+	var fx []byte
+	fxLine := 0
+	var code *Code
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(nil, len(data)+1)
+	var section *strings.Builder
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if m := sectionHeader.FindStringSubmatch(text); m != nil {
+			if code != nil {
+				code.Source = section.String()
+			}
+			code = nil
+			if m[1] == "FX" {
+				if fxLine != 0 {
+					return nil, &Error{File: file, Line: line, Msg: "second [[FX]] section"}
+				}
+				fxLine = line
+				continue
+			}
+			e.Code = append(e.Code, Code{Name: m[1], Line: line})
+			code = &e.Code[len(e.Code)-1]
+			section = &strings.Builder{}
+			continue
+		}
+		switch {
+		case code != nil:
+			section.WriteString(text)
+			section.WriteByte('\n')
+			if m := includeLine.FindStringSubmatch(text); m != nil {
+				code.Includes = append(code.Includes, m[1])
+			}
+		case fxLine != 0:
+			fx = append(fx, text...)
+			fx = append(fx, '\n')
+		case strings.TrimSpace(text) != "":
+			return nil, &Error{File: file, Line: line, Msg: "text before the first section"}
+		}
+	}
+	if code != nil {
+		code.Source = section.String()
+	}
+
+	if fxLine == 0 {
+		return nil, &Error{File: file, Msg: "no [[FX]] section"}
+	}
+	if err := e.parseFX(file, fxLine+1, string(fx)); err != nil {
+		return nil, err
+	}
+	e.addFlags(string(fx))
+	for _, c := range e.Code {
+		e.addFlags(c.Source)
+	}
+	return e, nil
+}
+
+func (e *Effect) addFlags(text string) {
+	for _, m := range flagName.FindAllStringSubmatch(text, -1) {
+		if slices.ContainsFunc(e.Flags, func(f Flag) bool { return f.Name == m[0] }) {
+			continue
+		}
+		bit, _ := strconv.Atoi(m[1])
+		e.Flags = append(e.Flags, Flag{Name: m[0], Bit: bit})
+	}
+}
+
+// includer reads and expands included code resources, caching their contents
+type includer struct {
+	fsys  fs.FS
+	files map[string]string
+}
+
+// expand replaces the include lines of source, which starts at line of file.  stack holds the
+// files being expanded, to detect include cycles.
+func (l *includer) expand(file string, line int, source string, stack []string) (string, error) {
+	var b strings.Builder
+	for i, text := range strings.SplitAfter(source, "\n") {
+		m := includeLine.FindStringSubmatch(text)
+		if m == nil {
+			b.WriteString(text)
+			continue
+		}
+		name := m[1]
+		if slices.Contains(stack, name) {
+			return "", &Error{File: file, Line: line + i + 1, Msg: "include cycle through " + name}
+		}
+		included, ok := l.files[name]
+		if !ok {
+			data, err := readResource(l.fsys, resTypeCode, name)
+			if err != nil {
+				return "", &Error{File: file, Line: line + i + 1, Msg: err.Error()}
+			}
+			included = string(data)
+			l.files[name] = included
+		}
+		expanded, err := l.expand(name, 0, included, append(stack, name))
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(expanded)
+		if !strings.HasSuffix(expanded, "\n") {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String(), nil
+}
+
+// Resource types of shaders and code, as numbered by the engine
+const (
+	resTypeCode   = 5
+	resTypeShader = 6
+)
+
+// resourceFS is the ResourceFS interface of the horde3d package
+type resourceFS interface {
+	fs.FS
+	OpenResource(resType int, name string) (fs.File, error)
+}
+
+func readResource(fsys fs.FS, resType int, name string) ([]byte, error) {
+	rfs, ok := fsys.(resourceFS)
+	if !ok {
+		return fs.ReadFile(fsys, resourcePath(name))
+	}
+	f, err := rfs.OpenResource(resType, name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// resourcePath turns a resource name into a path valid for an fs.FS
+func resourcePath(name string) string {
+	return path.Clean(strings.TrimLeft(name, "/"))
+}
+
+// Sampler returns the sampler with the given name, or nil
+func (e *Effect) Sampler(name string) *Sampler {
+	i := slices.IndexFunc(e.Samplers, func(s Sampler) bool { return s.Name == name })
+	if i < 0 {
+		return nil
+	}
+	return &e.Samplers[i]
+}
+
+// Uniform returns the uniform with the given name, or nil
+func (e *Effect) Uniform(name string) *Uniform {
+	i := slices.IndexFunc(e.Uniforms, func(u Uniform) bool { return u.Name == name })
+	if i < 0 {
+		return nil
+	}
+	return &e.Uniforms[i]
+}
+
+// Context returns the context with the given name, or nil
+func (e *Effect) Context(name string) *Context {
+	i := slices.IndexFunc(e.Contexts, func(c Context) bool { return c.Name == name })
+	if i < 0 {
+		return nil
+	}
+	return &e.Contexts[i]
+}
+
+// Section returns the code section with the given name, or nil
+func (e *Effect) Section(name string) *Code {
+	i := slices.IndexFunc(e.Code, func(c Code) bool { return c.Name == name })
+	if i < 0 {
+		return nil
+	}
+	return &e.Code[i]
+}
+
+func (e *Effect) HasSampler(name string) bool {
+	return e.Sampler(name) != nil
+}
+
+func (e *Effect) HasUniform(name string) bool {
+	return e.Uniform(name) != nil
+}
+
+func (e *Effect) HasContext(name string) bool {
+	return e.Context(name) != nil
+}
@@ -0,0 +1,247 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package shader
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const contentDir = "../../../examples/content"
+
+var exampleShaders = []string{
+	"shaders/deferredLighting.shader",
+	"shaders/model.shader",
+	"shaders/overlay.shader",
+	"shaders/particle.shader",
+	"shaders/postHDR.shader",
+	"shaders/skybox.shader",
+}
+
+func TestExampleShaders(t *testing.T) {
+	fsys := os.DirFS(contentDir)
+	for _, name := range exampleShaders {
+		f, err := os.Open(contentDir + "/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := Parse(f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(parsed.Contexts) == 0 || len(parsed.Code) == 0 {
+			t.Errorf("%s: %d contexts and %d code sections", name, len(parsed.Contexts),
+				len(parsed.Code))
+		}
+
+		e, err := Load(fsys, name)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := e.Validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+		for _, c := range e.Code {
+			if c.Expanded == "" || strings.Contains(c.Expanded, "#include") {
+				t.Errorf("%s: [[%s]] is not expanded", name, c.Name)
+			}
+		}
+		for _, c := range e.Contexts {
+			if e.Section(c.VertexShader) == nil || e.Section(c.PixelShader) == nil {
+				t.Errorf("%s: context %s has no code", name, c.Name)
+			}
+		}
+	}
+}
+
+func TestModelShader(t *testing.T) {
+	e, err := Load(os.DirFS(contentDir), "shaders/model.shader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []string{"ATTRIBPASS", "SHADOWMAP", "LIGHTING", "AMBIENT"} {
+		if !e.HasContext(c) {
+			t.Errorf("no context %s", c)
+		}
+	}
+	if !e.HasSampler("albedoMap") || e.HasSampler("nothing") || e.HasContext("NOTHING") {
+		t.Error("wrong samplers or contexts")
+	}
+	skinning := false
+	for _, f := range e.Flags {
+		skinning = skinning || f == Flag{Name: "_F01_Skinning", Bit: 1}
+	}
+	if !skinning {
+		t.Errorf("flags %+v", e.Flags)
+	}
+	vs := e.Section(e.Context("LIGHTING").VertexShader)
+	if !strings.Contains(vs.Expanded, "calcWorldPos") {
+		t.Error("vertCommon.glsl is not included")
+	}
+}
+
+const validShader = `[[FX]]
+sampler2D albedoMap;
+float4 color = {1, 1, 1, 1};
+context MAIN
+{
+	VertexShader = compile GLSL VS;
+	PixelShader = compile GLSL FS;
+}
+
+[[VS]]
+#include "shaders/lib.glsl"
+void main() {}
+
+[[FS]]
+void main() {}
+`
+
+func TestValidateErrors(t *testing.T) {
+	for _, test := range []struct {
+		name, src, want string
+	}{
+		{"missing code", strings.Replace(validShader, "[[FS]]", "[[PS]]", 1),
+			"compiles missing code section [[FS]]"},
+		{"duplicate bit", validShader + "#ifdef _F02_Skinning\n#endif\n#ifdef _F02_Other\n#endif\n",
+			"flag bit 02 is declared twice, by _F02_Skinning and _F02_Other"},
+		{"bad bit", validShader + "#ifdef _F40_Skinning\n#endif\n", "flag _F40_Skinning has bit 40"},
+		{"second FX", validShader + "[[FX]]\n", "second [[FX]] section"},
+		{"duplicate context", strings.Replace(validShader, "context MAIN",
+			"context MAIN { VertexShader = compile GLSL VS; PixelShader = compile GLSL FS; }\ncontext MAIN",
+			1), "context MAIN is defined twice"},
+	} {
+		e, err := Parse(strings.NewReader(test.src))
+		if err == nil {
+			err = e.Validate()
+		}
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got %v, want %q", test.name, err, test.want)
+		}
+		var serr *Error
+		if !errors.As(err, &serr) {
+			t.Errorf("%s: %T is not an *Error", test.name, err)
+		}
+	}
+
+	e, err := Parse(strings.NewReader(validShader))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := e.Validate(); err != nil {
+		t.Error(err)
+	}
+	if len(e.Code[0].Includes) != 1 || e.Code[0].Includes[0] != "shaders/lib.glsl" {
+		t.Errorf("includes %v", e.Code[0].Includes)
+	}
+}
+
+func TestIncludes(t *testing.T) {
+	fsys := fstest.MapFS{
+		"shaders/main.shader": {Data: []byte(validShader)},
+		"shaders/lib.glsl":    {Data: []byte("#include \"shaders/common.glsl\"\nfloat lib;\n")},
+		"shaders/common.glsl": {Data: []byte("float common; // _F03_Fog\n")},
+	}
+	e, err := Load(fsys, "/shaders/main.shader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "float common; // _F03_Fog\nfloat lib;\nvoid main() {}\n\n"
+	if got := e.Section("VS").Expanded; got != want {
+		t.Errorf("expanded to %q, want %q", got, want)
+	}
+	if len(e.Flags) != 1 || e.Flags[0].Name != "_F03_Fog" {
+		t.Errorf("flags of included code %+v", e.Flags)
+	}
+
+	fsys["shaders/common.glsl"] = &fstest.MapFile{Data: []byte("#include \"shaders/lib.glsl\"\n")}
+	_, err = Load(fsys, "shaders/main.shader")
+	var serr *Error
+	if !errors.As(err, &serr) || serr.File != "shaders/common.glsl" ||
+		!strings.Contains(serr.Msg, "include cycle through shaders/lib.glsl") {
+		t.Errorf("cycle: %v", err)
+	}
+
+	delete(fsys, "shaders/lib.glsl")
+	if _, err := Load(fsys, "shaders/main.shader"); !errors.As(err, &serr) ||
+		serr.File != "shaders/main.shader" || serr.Line != 11 {
+		t.Errorf("missing include: %v", err)
+	}
+}
+
+// prefixFS opens resources from a directory per resource type, like a VFS with resource paths
+type prefixFS struct {
+	fs.FS
+	dirs map[int]string
+}
+
+func (p prefixFS) OpenResource(resType int, name string) (fs.File, error) {
+	return p.Open(path.Join(p.dirs[resType], name))
+}
+
+func TestResourceFS(t *testing.T) {
+	fsys := prefixFS{
+		FS: fstest.MapFS{
+			"fx/main.shader": {Data: []byte(validShader)},
+			// the include of validShader is shaders/lib.glsl
+			"glsl/shaders/lib.glsl": {Data: []byte("float lib;\n")},
+		},
+		dirs: map[int]string{resTypeShader: "fx", resTypeCode: "glsl"},
+	}
+	e, err := Load(fsys, "main.shader")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := e.Section("VS").Expanded; !strings.HasPrefix(got, "float lib;\n") {
+		t.Errorf("expanded to %q", got)
+	}
+	if _, err := Load(fsys, "fx/main.shader"); err == nil {
+		t.Error("the shader prefix is not applied")
+	}
+}
+
+func TestBrokenFX(t *testing.T) {
+	for _, fx := range []string{
+		"sampler2D albedoMap",
+		"float4 color = {1, 1;",
+		"context MAIN { VertexShader = compile GLSL VS; ",
+		"context MAIN { VertexShader = VS; }",
+		"texture2D albedoMap;",
+		"sampler2D albedoMap < Address = Clamp; >;",
+	} {
+		if _, err := Parse(strings.NewReader("[[FX]]\n" + fx + "\n")); err == nil {
+			t.Errorf("%q parses", fx)
+		} else if serr := (*Error)(nil); !errors.As(err, &serr) || serr.Line == 0 {
+			t.Errorf("%q: %v has no line", fx, err)
+		}
+	}
+	for _, src := range []string{"", "[[VS]]\nvoid main() {}\n", "text\n[[FX]]\n"} {
+		if _, err := Parse(strings.NewReader(src)); err == nil {
+			t.Errorf("%q parses", src)
+		}
+	}
+}
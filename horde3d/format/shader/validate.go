@@ -0,0 +1,91 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package shader
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Validate checks that names are declared once, that the contexts compile code sections that
+// exist, and that every flag has a valid bit that no other flag uses.  The returned error joins
+// an *Error for every problem.
+func (e *Effect) Validate() error {
+	var errs []error
+	fail := func(line int, format string, args ...any) {
+		errs = append(errs, &Error{File: e.Name, Line: line, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	declared := make(map[string]string)
+	declare := func(name string, kind string, line int) {
+		if other, ok := declared[name]; ok {
+			fail(line, "%s %s is already declared as %s", kind, name, other)
+			return
+		}
+		declared[name] = kind
+	}
+	for _, s := range e.Samplers {
+		declare(s.Name, "sampler", s.Line)
+	}
+	for _, u := range e.Uniforms {
+		declare(u.Name, "uniform", u.Line)
+	}
+
+	sections := make(map[string]bool)
+	for _, c := range e.Code {
+		if sections[c.Name] {
+			fail(c.Line, "code section [[%s]] is defined twice", c.Name)
+		}
+		sections[c.Name] = true
+	}
+
+	contexts := make(map[string]bool)
+	for _, c := range e.Contexts {
+		if contexts[c.Name] {
+			fail(c.Line, "context %s is defined twice", c.Name)
+		}
+		contexts[c.Name] = true
+		for _, ref := range []struct{ key, section string }{
+			{"VertexShader", c.VertexShader}, {"PixelShader", c.PixelShader},
+		} {
+			switch {
+			case ref.section == "":
+				fail(c.Line, "context %s has no %s", c.Name, ref.key)
+			case !sections[ref.section]:
+				fail(c.Line, "context %s compiles missing code section [[%s]]", c.Name, ref.section)
+			}
+		}
+	}
+
+	bits := make(map[int]string)
+	for _, f := range e.Flags {
+		if f.Bit < 1 || f.Bit > 32 {
+			fail(0, "flag %s has bit %d, flags are numbered 01 to 32", f.Name, f.Bit)
+			continue
+		}
+		if other, ok := bits[f.Bit]; ok {
+			fail(0, "flag bit %02d is declared twice, by %s and %s", f.Bit, other, f.Name)
+			continue
+		}
+		bits[f.Bit] = f.Name
+	}
+	return errors.Join(errs...)
+}
@@ -73,8 +73,10 @@ DumpFailedShaders   - Enables or disables storing of shader code that failed to
                       useful in combination with the line numbers given back by the shader compiler. (Values: 0, 1; Default: 0)
 GatherTimeStats     - Enables or disables gathering of time stats that are useful for profiling (Values: 0, 1; Default: 1)
 */
+type Option int
+
 const (
-	_ = iota
+	_ Option = iota
 	Options_MaxLogLevel
 	Options_MaxNumMessages
 	Options_TrilinearFiltering
@@ -108,8 +110,10 @@ ParticleGPUTime   - GPU time in ms spent for drawing particles
 TextureVMem       - Estimated amount of video memory used by textures (in Mb)
 GeometryVMem      - Estimated amount of video memory used by geometry (in Mb)
 */
+type Stat int
+
 const (
-	Stats_TriCount = iota + 100
+	Stats_TriCount Stat = iota + 100
 	Stats_BatchCount
 	Stats_LightPassCount
 	Stats_FrameTime
@@ -598,18 +602,37 @@ func Message(level *int, time *float32) string {
 	return C.GoString(message)
 }
 
-func Option(param int) float32 {
+func (param Option) Value() float32 {
 	return float32(C.h3dGetOption(C.int(param)))
 }
 
-func SetOption(param int, value float32) bool {
+// GetOption returns the value of an engine option.
+//
+// Deprecated: Option is a type now, use Option.Value.
+func GetOption(param int) float32 {
+	return Option(param).Value()
+}
+
+// SetOption sets an engine option.  It returns false without calling the engine for values
+// outside of the documented range, use SetOptionErr to find out why a value was rejected.
+func SetOption(param Option, value float32) bool {
+	if param.Validate(value) != nil {
+		return false
+	}
 	return Bool[int(C.h3dSetOption(C.int(param), C.float(value)))]
 }
 
-func Stat(param int, reset bool) float32 {
+func (param Stat) Value(reset bool) float32 {
 	return float32(C.h3dGetStat(C.int(param), Int[reset]))
 }
 
+// GetStat returns an engine statistic.
+//
+// Deprecated: Stat is a type now, use Stat.Value or ReadFrameStats.
+func GetStat(param int, reset bool) float32 {
+	return Stat(param).Value(reset)
+}
+
 func ShowOverlays(verts []float32,
 	vertCount int,
 	colR float32,
@@ -674,7 +697,11 @@ func (res H3DRes) IsLoaded() bool {
 }
 
 func (res H3DRes) Load(data []byte) bool {
-	return Bool[int(C.h3dLoadResource(C.H3DRes(res), (*C.char)(unsafe.Pointer(&data[0])), C.int(len(data))))]
+	var cData *C.char
+	if len(data) > 0 {
+		cData = (*C.char)(unsafe.Pointer(&data[0]))
+	}
+	return Bool[int(C.h3dLoadResource(C.H3DRes(res), cData, C.int(len(data))))]
 }
 
 func (res H3DRes) Unload() {
@@ -737,6 +764,8 @@ func prepSlice(slicePtr unsafe.Pointer, cArrayPtr unsafe.Pointer, length int) {
 	slcHead.Data = uintptr(cArrayPtr)
 }
 
+// Deprecated: the caller has to get length right and unmap the stream, use the Map methods of
+// Geometry and Texture instead.
 func (res H3DRes) MapUint8ResStream(elem int, elemIdx int, stream int, read bool, write bool, length int) ([]uint8, error) {
 	ptr := res.MapResStream(elem, elemIdx, stream, read, write)
 
@@ -750,6 +779,8 @@ func (res H3DRes) MapUint8ResStream(elem int, elemIdx int, stream int, read bool
 	return slice, nil
 }
 
+// Deprecated: the caller has to get length right and unmap the stream, use the Map methods of
+// Geometry and Texture instead.
 func (res H3DRes) MapUint16ResStream(elem int, elemIdx int, stream int, read bool, write bool, length int) ([]uint16, error) {
 	ptr := res.MapResStream(elem, elemIdx, stream, read, write)
 
@@ -763,6 +794,8 @@ func (res H3DRes) MapUint16ResStream(elem int, elemIdx int, stream int, read boo
 	return slice, nil
 }
 
+// Deprecated: the caller has to get length right and unmap the stream, use the Map methods of
+// Geometry and Texture instead.
 func (res H3DRes) MapUint32ResStream(elem int, elemIdx int, stream int, read bool, write bool, length int) ([]uint32, error) {
 	ptr := res.MapResStream(elem, elemIdx, stream, read, write)
 
@@ -775,6 +808,8 @@ func (res H3DRes) MapUint32ResStream(elem int, elemIdx int, stream int, read boo
 	return slice, nil
 }
 
+// Deprecated: the caller has to get length right and unmap the stream, use the Map methods of
+// Geometry and Texture instead.
 func (res H3DRes) MapFloatResStream(elem int, elemIdx int, stream int, read bool, write bool, length int) ([]float32, error) {
 	ptr := res.MapResStream(elem, elemIdx, stream, read, write)
 
@@ -787,6 +822,8 @@ func (res H3DRes) MapFloatResStream(elem int, elemIdx int, stream int, read bool
 	return slice, nil
 }
 
+// Deprecated: the caller has to get length right and unmap the stream, use the Map methods of
+// Geometry and Texture instead.
 func (res H3DRes) MapByteResStream(elem int, elemIdx int, stream int, read bool, write bool, length int) ([]byte, error) {
 	ptr := res.MapResStream(elem, elemIdx, stream, read, write)
 
@@ -942,7 +979,7 @@ func (node H3DNode) NodeParamStr(param int) string {
 
 func (node H3DNode) SetNodeParamStr(param int, value string) {
 	cValue := C.CString(value)
-	C.free(unsafe.Pointer(cValue))
+	defer C.free(unsafe.Pointer(cValue))
 	C.h3dSetNodeParamStr(C.H3DNode(node), C.int(param), cValue)
 }
 
@@ -962,25 +999,40 @@ func (node H3DNode) AABB(minX *float32, minY *float32, minZ *float32,
 		(*C.float)(unsafe.Pointer(maxZ)))
 }
 
-func FindNodes(node H3DNode, name string, nodeType int) int {
+// FindNodes returns the nodes below and including node that match name and nodeType.  An empty
+// name matches every name, NodeTypes_Undefined every type.
+func FindNodes(node H3DNode, name string, nodeType int) []H3DNode {
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
-	return int(C.h3dFindNodes(C.H3DNode(node), cName, C.int(nodeType)))
-}
-
-func GetNodeFindResult(index int) H3DNode {
-	return H3DNode(C.h3dGetNodeFindResult(C.int(index)))
-}
+	count := int(C.h3dFindNodes(C.H3DNode(node), cName, C.int(nodeType)))
 
-func (node H3DNode) CastRay(ox float32, oy float32, oz float32,
-	dx float32, dy float32, dz float32, numNearest int) int {
-	return int(C.h3dCastRay(C.H3DNode(node), C.float(ox), C.float(oy), C.float(oz),
-		C.float(dx), C.float(dy), C.float(dz), C.int(numNearest)))
-}
-
-func CastRayResult(index int, node *H3DNode, distance *float32, intersection *[3]float32) bool {
-	return Bool[int(C.h3dGetCastRayResult(C.int(index), (*C.H3DNode)(unsafe.Pointer(node)),
-		(*C.float)(unsafe.Pointer(distance)), (*C.float)(unsafe.Pointer(&intersection[0]))))]
+	nodes := make([]H3DNode, count)
+	for i := range nodes {
+		nodes[i] = H3DNode(C.h3dGetNodeFindResult(C.int(i)))
+	}
+	return nodes
+}
+
+// CastRay returns up to numNearest nodes below and including node hit by ray, sorted by distance.
+// A numNearest of 0 returns every hit.  The direction of the ray also sets its length.
+func (node H3DNode) CastRay(ray Ray, numNearest int) []RayHit {
+	count := int(C.h3dCastRay(C.H3DNode(node), C.float(ray.Origin.X), C.float(ray.Origin.Y),
+		C.float(ray.Origin.Z), C.float(ray.Direction.X), C.float(ray.Direction.Y),
+		C.float(ray.Direction.Z), C.int(numNearest)))
+
+	hits := make([]RayHit, 0, count)
+	for i := 0; i < count; i++ {
+		var hit RayHit
+		var intersection [3]C.float
+		if C.h3dGetCastRayResult(C.int(i), (*C.H3DNode)(unsafe.Pointer(&hit.Node)),
+			(*C.float)(unsafe.Pointer(&hit.Distance)), &intersection[0]) == 0 {
+			break
+		}
+		hit.Point = Vec3{X: float32(intersection[0]), Y: float32(intersection[1]),
+			Z: float32(intersection[2])}
+		hits = append(hits, hit)
+	}
+	return hits
 }
 
 func (node H3DNode) CheckNodeVisibility(cameraNode H3DNode, checkOcclusion bool, calcLod bool) int {
@@ -994,10 +1046,10 @@ func (parent H3DNode) AddGroupNode(name string) H3DNode {
 	return H3DNode(C.h3dAddGroupNode(C.H3DNode(parent), cName))
 }
 
-func (parent H3DNode) AddModelNode(name string, geometryRes H3DRes) H3DNode {
+func (parent H3DNode) AddModelNode(name string, geometry Geometry) ModelNode {
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
-	return H3DNode(C.h3dAddModelNode(C.H3DNode(parent), cName, C.H3DRes(geometryRes)))
+	return ModelNode{H3DNode(C.h3dAddModelNode(C.H3DNode(parent), cName, C.H3DRes(geometry.res)))}
 }
 
 func SetupModelAnimStage(modelNode H3DNode, stage int, animationRes H3DRes, layer int,
@@ -1018,22 +1070,22 @@ func SetModelMorpher(modelNode H3DNode, target string, weight float32) bool {
 	return Bool[int(C.h3dSetModelMorpher(C.H3DNode(modelNode), cTarget, C.float(weight)))]
 }
 
-func (parent H3DNode) AddMeshNode(name string, materialRes H3DRes, batchStart int, batchCount int,
-	vertRStart int, vertEnd int) H3DNode {
+func (parent H3DNode) AddMeshNode(name string, material Material, batchStart int, batchCount int,
+	vertRStart int, vertEnd int) MeshNode {
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
-	return H3DNode(C.h3dAddMeshNode(C.H3DNode(parent), cName, C.H3DRes(materialRes), C.int(batchStart),
-		C.int(batchCount), C.int(vertRStart), C.int(vertEnd)))
+	return MeshNode{H3DNode(C.h3dAddMeshNode(C.H3DNode(parent), cName, C.H3DRes(material.res),
+		C.int(batchStart), C.int(batchCount), C.int(vertRStart), C.int(vertEnd)))}
 }
 
-func (parent H3DNode) AddJointNode(name string, jointIndex int) H3DNode {
+func (parent H3DNode) AddJointNode(name string, jointIndex int) JointNode {
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
-	return H3DNode(C.h3dAddJointNode(C.H3DNode(parent), cName, C.int(jointIndex)))
+	return JointNode{H3DNode(C.h3dAddJointNode(C.H3DNode(parent), cName, C.int(jointIndex)))}
 }
 
-func (parent H3DNode) AddLightNode(name string, materialRes H3DRes, lightingContext string,
-	shadowContext string) H3DNode {
+func (parent H3DNode) AddLightNode(name string, material Material, lightingContext string,
+	shadowContext string) LightNode {
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 	cLightingContext := C.CString(lightingContext)
@@ -1041,15 +1093,15 @@ func (parent H3DNode) AddLightNode(name string, materialRes H3DRes, lightingCont
 	cShadowContext := C.CString(shadowContext)
 	defer C.free(unsafe.Pointer(cShadowContext))
 
-	return H3DNode(C.h3dAddLightNode(C.H3DNode(parent), cName, C.H3DRes(materialRes), cLightingContext,
-		cShadowContext))
+	return LightNode{H3DNode(C.h3dAddLightNode(C.H3DNode(parent), cName, C.H3DRes(material.res),
+		cLightingContext, cShadowContext))}
 }
 
-func (parent H3DNode) AddCameraNode(name string, pipelineRes H3DRes) H3DNode {
+func (parent H3DNode) AddCameraNode(name string, pipeline Pipeline) CameraNode {
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
 
-	return H3DNode(C.h3dAddCameraNode(C.H3DNode(parent), cName, C.H3DRes(pipelineRes)))
+	return CameraNode{H3DNode(C.h3dAddCameraNode(C.H3DNode(parent), cName, C.H3DRes(pipeline.res)))}
 }
 
 func SetupCameraView(cameraNode H3DNode, fov float32, aspect float32,
@@ -1063,12 +1115,12 @@ func GetCameraProjMat(cameraNode H3DNode, projMat *[16]float32) {
 	C.h3dGetCameraProjMat(C.H3DNode(cameraNode), (*C.float)(unsafe.Pointer(&projMat[0])))
 }
 
-func (parent H3DNode) AddEmitterNode(name string, materialRes H3DRes, particleEffectRes H3DRes,
-	maxParticleCount int, respawnCount int) H3DNode {
+func (parent H3DNode) AddEmitterNode(name string, material Material, particleEffect ParticleEffect,
+	maxParticleCount int, respawnCount int) EmitterNode {
 	cName := C.CString(name)
 	defer C.free(unsafe.Pointer(cName))
-	return H3DNode(C.h3dAddEmitterNode(C.H3DNode(parent), cName, C.H3DRes(materialRes),
-		C.H3DRes(particleEffectRes), C.int(maxParticleCount), C.int(respawnCount)))
+	return EmitterNode{H3DNode(C.h3dAddEmitterNode(C.H3DNode(parent), cName, C.H3DRes(material.res),
+		C.H3DRes(particleEffect.res), C.int(maxParticleCount), C.int(respawnCount)))}
 }
 
 func UpdateEmitter(emitterNode H3DNode, timeDelta float32) {
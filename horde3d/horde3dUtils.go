@@ -46,8 +46,8 @@ func DumpMessages() bool {
 h3dutInitOpenGL
 h3dutReleaseOpenGL
 h3dutSwapBuffers
-h3dutGetResourcePath
-h3dutSetResourcePath
+h3dutGetResourcePath - see VFS.ResourcePath
+h3dutSetResourcePath - see VFS.SetResourcePath
 */
 
 func LoadResourcesFromDisk(contentDir string) bool {
@@ -66,21 +66,21 @@ func CreateGeometryRes(name string, numVertices int, numTriangleIndices int, pos
 	var normal, tangent, bitangent *C.short
 	var text1, text2 *C.float
 
-	if normalData != nil {
+	if len(normalData) > 0 {
 		normal = (*C.short)(unsafe.Pointer(&normalData[0]))
 	}
-	if tangentData != nil {
+	if len(tangentData) > 0 {
 		tangent = (*C.short)(unsafe.Pointer(&tangentData[0]))
 	}
-	if bitangentData != nil {
+	if len(bitangentData) > 0 {
 		bitangent = (*C.short)(unsafe.Pointer(&bitangentData[0]))
 	}
 
-	if textData1 != nil {
+	if len(textData1) > 0 {
 		text1 = (*C.float)(unsafe.Pointer(&textData1[0]))
 	}
 
-	if textData2 != nil {
+	if len(textData2) > 0 {
 		text2 = (*C.float)(unsafe.Pointer(&textData2[0]))
 	}
 
@@ -99,12 +99,13 @@ func Screenshot(filename string) bool {
 
 	return Bool[int(C.h3dutScreenshot(cFilename))]
 }
-func PickRay(cameraNode H3DNode, nwx float32, nwy float32, ox *float32, oy *float32, oz *float32,
-	dx *float32, dy *float32, dz *float32) {
-	C.h3dutPickRay(C.H3DNode(cameraNode), C.float(nwx), C.float(nwy), (*C.float)(unsafe.Pointer(ox)),
-		(*C.float)(unsafe.Pointer(oy)), (*C.float)(unsafe.Pointer(oz)),
-		(*C.float)(unsafe.Pointer(dx)), (*C.float)(unsafe.Pointer(dy)),
-		(*C.float)(unsafe.Pointer(dz)))
+func PickRay(cameraNode H3DNode, nwx float32, nwy float32) Ray {
+	var r Ray
+	C.h3dutPickRay(C.H3DNode(cameraNode), C.float(nwx), C.float(nwy), (*C.float)(&r.Origin.X),
+		(*C.float)(&r.Origin.Y), (*C.float)(&r.Origin.Z),
+		(*C.float)(&r.Direction.X), (*C.float)(&r.Direction.Y),
+		(*C.float)(&r.Direction.Z))
+	return r
 }
 
 func PickNode(cameraNode H3DNode, nwx float32, nwy float32) H3DNode {
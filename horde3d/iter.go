@@ -0,0 +1,66 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import "iter"
+
+// Children iterates over the direct children of the node
+func (node H3DNode) Children() iter.Seq[H3DNode] {
+	return func(yield func(H3DNode) bool) {
+		for i := 0; ; i++ {
+			child := node.Child(i)
+			if child == 0 || !yield(child) {
+				return
+			}
+		}
+	}
+}
+
+// Resources iterates over all resources of resType
+func Resources(resType int) iter.Seq[H3DRes] {
+	return func(yield func(H3DRes) bool) {
+		for res := NextResource(resType, 0); res != 0; res = NextResource(resType, res) {
+			if !yield(res) {
+				return
+			}
+		}
+	}
+}
+
+// UnloadedResources iterates over the resources that still have to be loaded.  Resources may be
+// loaded while iterating, and resources they reference that are added by loading them are
+// iterated as well.
+func UnloadedResources() iter.Seq[H3DRes] {
+	return func(yield func(H3DRes) bool) {
+		i := 0
+		for {
+			res := QueryUnloadedResource(i)
+			if res == 0 || !yield(res) {
+				return
+			}
+			// a resource that was loaded, or loaded without data, drops out of the list and the
+			// next one takes its place
+			if QueryUnloadedResource(i) == res {
+				i++
+			}
+		}
+	}
+}
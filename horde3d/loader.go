@@ -0,0 +1,88 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ResourceLoadError reports a single resource that could not be loaded
+type ResourceLoadError struct {
+	Name string
+	Type int
+	Err  error
+}
+
+func (e *ResourceLoadError) Error() string {
+	return "horde3d: loading " + resTypeName(e.Type) + " " + strconv.Quote(e.Name) + ": " + e.Err.Error()
+}
+
+func (e *ResourceLoadError) Unwrap() error {
+	return e.Err
+}
+
+// LoadResourcesFromFS loads all unloaded resources by reading the files named after them from
+// fsys, including resources that are added while loading others, such as the textures of a
+// material.  If fsys is a ResourceFS, like a VFS, the files are opened through OpenResource.
+// As with LoadResourcesFromDisk, resources whose file can't be read are loaded
+// without data so the engine falls back to their defaults.  The returned error joins a
+// *ResourceLoadError for every resource that failed.
+func LoadResourcesFromFS(fsys fs.FS) error {
+	var errs []error
+	for res := range UnloadedResources() {
+		if err := loadResourceFromFS(fsys, res); err != nil {
+			errs = append(errs, &ResourceLoadError{Name: res.Name(), Type: res.Type(), Err: err})
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func loadResourceFromFS(fsys fs.FS, res H3DRes) error {
+	data, err := readResource(fsys, res.Type(), res.Name())
+	if err != nil {
+		res.Load(nil)
+		return err
+	}
+	return res.LoadErr(data)
+}
+
+func readResource(fsys fs.FS, resType int, name string) ([]byte, error) {
+	rfs, ok := fsys.(ResourceFS)
+	if !ok {
+		return fs.ReadFile(fsys, resourcePath(name))
+	}
+	f, err := rfs.OpenResource(resType, name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// resourcePath turns a resource name into a path valid for an fs.FS
+func resourcePath(name string) string {
+	return path.Clean(strings.TrimLeft(name, "/"))
+}
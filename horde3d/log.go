@@ -0,0 +1,111 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// LogBridge forwards the engine message queue to a slog.Handler so engine diagnostics end up in
+// the same structured log as the rest of the application.
+type LogBridge struct {
+	handler  slog.Handler
+	maxLevel int
+	synced   bool // maxLevel has been set on the engine
+}
+
+func NewLogBridge(handler slog.Handler) *LogBridge {
+	return &LogBridge{handler: handler}
+}
+
+// SlogLevel maps a Horde3D message level to a slog level
+func SlogLevel(level int) slog.Level {
+	switch {
+	case level <= LogLevel_Error:
+		return slog.LevelError
+	case level == LogLevel_Warning:
+		return slog.LevelWarn
+	case level == LogLevel_Info:
+		return slog.LevelInfo
+	}
+	return slog.LevelDebug
+}
+
+// HordeLevel maps a slog level to the Horde3D message level that includes it
+func HordeLevel(level slog.Level) int {
+	switch {
+	case level >= slog.LevelError:
+		return LogLevel_Error
+	case level >= slog.LevelWarn:
+		return LogLevel_Warning
+	case level >= slog.LevelInfo:
+		return LogLevel_Info
+	}
+	return LogLevel_Debug
+}
+
+// SyncLevel sets Options_MaxLogLevel to the most verbose Horde3D level the handler is enabled
+// for, so the engine doesn't queue messages that would only be thrown away.
+func (b *LogBridge) SyncLevel(ctx context.Context) error {
+	maxLevel := 0
+	for level := LogLevel_Error; level <= LogLevel_Debug; level++ {
+		if b.handler.Enabled(ctx, SlogLevel(level)) {
+			maxLevel = level
+		}
+	}
+	if b.synced && maxLevel == b.maxLevel {
+		return nil
+	}
+	if err := SetOptionErr(Options_MaxLogLevel, float32(maxLevel)); err != nil {
+		return err
+	}
+	b.maxLevel, b.synced = maxLevel, true
+	return nil
+}
+
+// Flush drains the engine message queue into the handler.  Call it once per frame, usually
+// after FinalizeFrame, or whenever the messages are wanted.
+func (b *LogBridge) Flush(ctx context.Context) error {
+	if err := b.SyncLevel(ctx); err != nil {
+		return err
+	}
+	return b.Forward(ctx, drainMessages())
+}
+
+// Forward passes messages that were already taken off the queue, such as the ones held by an
+// EngineError, to the handler.
+func (b *LogBridge) Forward(ctx context.Context, msgs []LogMessage) error {
+	now := time.Now()
+	for _, m := range msgs {
+		level := SlogLevel(m.Level)
+		if !b.handler.Enabled(ctx, level) {
+			continue
+		}
+		r := slog.NewRecord(now, level, m.Text, 0)
+		r.AddAttrs(slog.Float64("engineTime", float64(m.Time)))
+		if err := b.handler.Handle(ctx, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ResourceManager keeps track of which owners hold which resources.  Every Acquire has to be
+// matched by a ReleaseRes of the same owner, and resources nobody holds anymore are removed from
+// the engine.  Owners are free form names, like the name of a level or a subsystem.
+type ResourceManager struct {
+	mu     sync.Mutex
+	names  map[resKey]H3DRes
+	owners map[H3DRes]map[string]int
+}
+
+type resKey struct {
+	resType int
+	name    string
+}
+
+// ResourceInfo describes a resource known to the engine
+type ResourceInfo struct {
+	Res       H3DRes
+	Type      int
+	Name      string
+	Loaded    bool
+	MappedMem int64          // estimated size of the mappable data in bytes
+	Owners    map[string]int // references held through the ResourceManager per owner
+}
+
+// LeakReport lists the resources that were still held when the ResourceManager was released
+type LeakReport struct {
+	Leaks []ResourceInfo
+}
+
+func NewResourceManager() *ResourceManager {
+	return &ResourceManager{
+		names:  make(map[resKey]H3DRes),
+		owners: make(map[H3DRes]map[string]int),
+	}
+}
+
+// Acquire adds the resource, or returns the handle of the resource already added under name,
+// and records a reference held by owner.  It returns 0 if the resource couldn't be added.
+func (m *ResourceManager) Acquire(owner string, resType int, name string, flags int) H3DRes {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := resKey{resType, name}
+	res, ok := m.names[key]
+	if !ok {
+		if res = AddResource(resType, name, flags); res == 0 {
+			return 0
+		}
+		m.names[key] = res
+		m.owners[res] = make(map[string]int)
+	}
+	m.owners[res][owner]++
+	return res
+}
+
+// ReleaseRes drops a reference owner holds on res.  Once no owner holds the resource anymore, it
+// is removed from the engine.
+func (m *ResourceManager) ReleaseRes(owner string, res H3DRes) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	held := m.owners[res]
+	if held[owner] == 0 {
+		return fmt.Errorf("%w: %s does not hold resource %d", ErrNotFound, owner, res)
+	}
+	held[owner]--
+	if held[owner] == 0 {
+		delete(held, owner)
+	}
+	if len(held) == 0 {
+		m.remove(res)
+	}
+	return nil
+}
+
+// ReleaseOwner drops all references held by owner and returns how many there were
+func (m *ResourceManager) ReleaseOwner(owner string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	count := 0
+	for res, held := range m.owners {
+		count += held[owner]
+		delete(held, owner)
+		if len(held) == 0 {
+			m.remove(res)
+		}
+	}
+	return count
+}
+
+func (m *ResourceManager) remove(res H3DRes) {
+	for key, r := range m.names {
+		if r == res {
+			delete(m.names, key)
+		}
+	}
+	delete(m.owners, res)
+	res.Remove()
+}
+
+// List returns all resources of the engine, including the ones not added through the manager
+func (m *ResourceManager) List() []ResourceInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var list []ResourceInfo
+	for res := range Resources(ResTypes_Undefined) {
+		list = append(list, m.info(res))
+	}
+	return list
+}
+
+func (m *ResourceManager) info(res H3DRes) ResourceInfo {
+	return ResourceInfo{
+		Res:       res,
+		Type:      res.Type(),
+		Name:      res.Name(),
+		Loaded:    res.IsLoaded(),
+		MappedMem: mappedSize(res),
+		Owners:    maps.Clone(m.owners[res]),
+	}
+}
+
+// Release removes all resources still held through the manager, frees the unused resources of
+// the engine and reports the resources that had not been released by their owners
+func (m *ResourceManager) Release() LeakReport {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var report LeakReport
+	for _, res := range slices.Sorted(maps.Keys(m.owners)) {
+		report.Leaks = append(report.Leaks, m.info(res))
+		m.remove(res)
+	}
+	ReleaseUnusedResources()
+	return report
+}
+
+func (r LeakReport) String() string {
+	if len(r.Leaks) == 0 {
+		return "no leaked resources"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d leaked resources:\n", len(r.Leaks))
+	for _, l := range r.Leaks {
+		fmt.Fprintf(&b, "  %s %q held by", resTypeName(l.Type), l.Name)
+		for _, owner := range slices.Sorted(maps.Keys(l.Owners)) {
+			fmt.Fprintf(&b, " %s (%d)", owner, l.Owners[owner])
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,323 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strconv"
+)
+
+// ErrInvalidDesc is returned when a resource description doesn't validate
+var ErrInvalidDesc = errors.New("horde3d: invalid description")
+
+// ShaderInfo describes the samplers, uniforms and contexts declared by a shader, for validating
+// descriptions against it.  Shader implements it, as does Effect of the format/shader package.
+type ShaderInfo interface {
+	HasSampler(name string) bool
+	HasUniform(name string) bool
+	HasContext(name string) bool
+}
+
+// MaterialDesc describes a material as in a .material.xml file.  Samplers and uniforms the
+// material doesn't set are looked up in the linked material.
+type MaterialDesc struct {
+	Class    string
+	Link     string // name of the linked material resource
+	Shader   string // name of the shader resource
+	Flags    []string
+	Samplers []SamplerDesc
+	Uniforms []UniformDesc
+}
+
+// SamplerDesc binds a texture to a sampler of the shader
+type SamplerDesc struct {
+	Name               string
+	Map                string // name of the texture resource
+	DisableCompression bool
+	DisableMipmaps     bool
+	SRGB               bool
+}
+
+// UniformDesc sets a uniform of the shader
+type UniformDesc struct {
+	Name  string
+	Value [4]float32
+}
+
+type materialXML struct {
+	XMLName  xml.Name     `xml:"Material"`
+	Class    string       `xml:"class,attr,omitempty"`
+	Link     string       `xml:"link,attr,omitempty"`
+	Shader   *sourceXML   `xml:"Shader"`
+	Flags    []nameXML    `xml:"ShaderFlag"`
+	Samplers []samplerXML `xml:"Sampler"`
+	Uniforms []uniformXML `xml:"Uniform"`
+}
+
+type sourceXML struct {
+	Source string `xml:"source,attr"`
+}
+
+type nameXML struct {
+	Name string `xml:"name,attr"`
+}
+
+type samplerXML struct {
+	Name             string `xml:"name,attr"`
+	Map              string `xml:"map,attr"`
+	AllowCompression *bool  `xml:"allowCompression,attr"`
+	Mipmaps          *bool  `xml:"mipmaps,attr"`
+	SRGB             *bool  `xml:"sRGB,attr"`
+}
+
+type uniformXML struct {
+	Name string  `xml:"name,attr"`
+	A    float32 `xml:"a,attr"`
+	B    float32 `xml:"b,attr,omitempty"`
+	C    float32 `xml:"c,attr,omitempty"`
+	D    float32 `xml:"d,attr,omitempty"`
+}
+
+// NewMaterialDesc starts the description of a material using shader
+func NewMaterialDesc(shader string) *MaterialDesc {
+	return &MaterialDesc{Shader: shader}
+}
+
+func (m *MaterialDesc) WithClass(class string) *MaterialDesc {
+	m.Class = class
+	return m
+}
+
+func (m *MaterialDesc) WithLink(material string) *MaterialDesc {
+	m.Link = material
+	return m
+}
+
+func (m *MaterialDesc) WithFlag(flag string) *MaterialDesc {
+	m.Flags = append(m.Flags, flag)
+	return m
+}
+
+func (m *MaterialDesc) WithSampler(name string, texture string) *MaterialDesc {
+	m.Samplers = append(m.Samplers, SamplerDesc{Name: name, Map: texture})
+	return m
+}
+
+func (m *MaterialDesc) WithUniform(name string, a float32, b float32, c float32,
+	d float32) *MaterialDesc {
+	m.Uniforms = append(m.Uniforms, UniformDesc{Name: name, Value: [4]float32{a, b, c, d}})
+	return m
+}
+
+// Sampler returns the sampler with the given name, or nil
+func (m *MaterialDesc) Sampler(name string) *SamplerDesc {
+	i := slices.IndexFunc(m.Samplers, func(s SamplerDesc) bool { return s.Name == name })
+	if i < 0 {
+		return nil
+	}
+	return &m.Samplers[i]
+}
+
+// Uniform returns the uniform with the given name, or nil
+func (m *MaterialDesc) Uniform(name string) *UniformDesc {
+	i := slices.IndexFunc(m.Uniforms, func(u UniformDesc) bool { return u.Name == name })
+	if i < 0 {
+		return nil
+	}
+	return &m.Uniforms[i]
+}
+
+// DecodeMaterialDesc reads a .material.xml file
+func DecodeMaterialDesc(r io.Reader) (*MaterialDesc, error) {
+	var x materialXML
+	if err := xml.NewDecoder(r).Decode(&x); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDesc, err)
+	}
+
+	m := &MaterialDesc{Class: x.Class, Link: x.Link}
+	if x.Shader != nil {
+		m.Shader = x.Shader.Source
+	}
+	for _, f := range x.Flags {
+		m.Flags = append(m.Flags, f.Name)
+	}
+	for _, s := range x.Samplers {
+		m.Samplers = append(m.Samplers, SamplerDesc{
+			Name:               s.Name,
+			Map:                s.Map,
+			DisableCompression: s.AllowCompression != nil && !*s.AllowCompression,
+			DisableMipmaps:     s.Mipmaps != nil && !*s.Mipmaps,
+			SRGB:               s.SRGB != nil && *s.SRGB,
+		})
+	}
+	for _, u := range x.Uniforms {
+		m.Uniforms = append(m.Uniforms, UniformDesc{Name: u.Name, Value: [4]float32{u.A, u.B, u.C, u.D}})
+	}
+	return m, nil
+}
+
+// Encode writes the material as a .material.xml file
+func (m *MaterialDesc) Encode(w io.Writer) error {
+	x := materialXML{Class: m.Class, Link: m.Link}
+	if m.Shader != "" {
+		x.Shader = &sourceXML{Source: m.Shader}
+	}
+	for _, f := range m.Flags {
+		x.Flags = append(x.Flags, nameXML{Name: f})
+	}
+	for _, s := range m.Samplers {
+		sx := samplerXML{Name: s.Name, Map: s.Map}
+		if s.DisableCompression {
+			sx.AllowCompression = new(bool)
+		}
+		if s.DisableMipmaps {
+			sx.Mipmaps = new(bool)
+		}
+		if s.SRGB {
+			sx.SRGB = &s.SRGB
+		}
+		x.Samplers = append(x.Samplers, sx)
+	}
+	for _, u := range m.Uniforms {
+		x.Uniforms = append(x.Uniforms, uniformXML{Name: u.Name, A: u.Value[0], B: u.Value[1],
+			C: u.Value[2], D: u.Value[3]})
+	}
+	return encodeXML(w, x)
+}
+
+func encodeXML(w io.Writer, v any) error {
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "\t")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Validate checks the shader flags and that samplers and uniforms aren't set twice.  If shader
+// is not nil, it also checks that the samplers and uniforms are declared by the shader.
+func (m *MaterialDesc) Validate(shader ShaderInfo) error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: material: %s", ErrInvalidDesc, fmt.Sprintf(format, args...)))
+	}
+
+	for _, f := range m.Flags {
+		if !validShaderFlag(f) {
+			fail("shader flag %q is not of the form _Fnn_Name with nn from 01 to 32", f)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, s := range m.Samplers {
+		if seen[s.Name] {
+			fail("sampler %q is set twice", s.Name)
+		}
+		seen[s.Name] = true
+		if s.Map == "" {
+			fail("sampler %q has no texture", s.Name)
+		}
+		if shader != nil && !shader.HasSampler(s.Name) {
+			fail("sampler %q is not declared by shader %q", s.Name, m.Shader)
+		}
+	}
+	clear(seen)
+	for _, u := range m.Uniforms {
+		if seen[u.Name] {
+			fail("uniform %q is set twice", u.Name)
+		}
+		seen[u.Name] = true
+		if shader != nil && !shader.HasUniform(u.Name) {
+			fail("uniform %q is not declared by shader %q", u.Name, m.Shader)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func validShaderFlag(flag string) bool {
+	if len(flag) < 5 || flag[:2] != "_F" || flag[4] != '_' {
+		return false
+	}
+	n, err := strconv.Atoi(flag[2:4])
+	return err == nil && n >= 1 && n <= 32
+}
+
+// Resolve follows the chain of linked materials, read from fsys, and returns a copy of the
+// material that sets every sampler and uniform the chain provides.  The copy has no link.
+func (m *MaterialDesc) Resolve(fsys fs.FS) (*MaterialDesc, error) {
+	resolved := m.clone()
+	resolved.Link = ""
+	visited := map[string]bool{}
+	for link := m.Link; link != ""; {
+		if visited[link] {
+			return nil, fmt.Errorf("%w: material link cycle at %q", ErrInvalidDesc, link)
+		}
+		visited[link] = true
+
+		data, err := readResource(fsys, ResTypes_Material, link)
+		if err != nil {
+			return nil, err
+		}
+		linked, err := DecodeMaterialDesc(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", link, err)
+		}
+		for _, s := range linked.Samplers {
+			if resolved.Sampler(s.Name) == nil {
+				resolved.Samplers = append(resolved.Samplers, s)
+			}
+		}
+		for _, u := range linked.Uniforms {
+			if resolved.Uniform(u.Name) == nil {
+				resolved.Uniforms = append(resolved.Uniforms, u)
+			}
+		}
+		link = linked.Link
+	}
+	return resolved, nil
+}
+
+func (m *MaterialDesc) clone() *MaterialDesc {
+	c := *m
+	c.Flags = slices.Clone(m.Flags)
+	c.Samplers = slices.Clone(m.Samplers)
+	c.Uniforms = slices.Clone(m.Uniforms)
+	return &c
+}
+
+// Create adds a material resource with the given name and loads the description into it
+func (m *MaterialDesc) Create(name string, flags int) (Material, error) {
+	var buf bytes.Buffer
+	if err := m.Encode(&buf); err != nil {
+		return Material{}, err
+	}
+	r, err := createResource(ResTypes_Material, name, flags, buf.Bytes())
+	return Material{r}, err
+}
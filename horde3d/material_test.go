@@ -0,0 +1,164 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// fakeShader declares the samplers, uniforms and contexts listed in it
+type fakeShader struct {
+	samplers, uniforms, contexts []string
+}
+
+func (s *fakeShader) HasSampler(name string) bool { return slices.Contains(s.samplers, name) }
+func (s *fakeShader) HasUniform(name string) bool { return slices.Contains(s.uniforms, name) }
+func (s *fakeShader) HasContext(name string) bool { return slices.Contains(s.contexts, name) }
+
+func TestMaterialCodec(t *testing.T) {
+	data, err := os.ReadFile(contentDir + "/models/knight/knight.material.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := DecodeMaterialDesc(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &MaterialDesc{
+		Shader: "shaders/model.shader",
+		Flags:  []string{"_F01_Skinning", "_F04_EnvMapping"},
+		Samplers: []SamplerDesc{
+			{Name: "albedoMap", Map: "models/knight/knight.jpg"},
+			{Name: "envMap", Map: "models/skybox/skybox.dds"},
+		},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("decoded %+v, want %+v", m, want)
+	}
+
+	built := NewMaterialDesc("shaders/model.shader").WithClass("Translucent.Glass").
+		WithLink("pipelines/globalSettings.material.xml").WithFlag("_F02_NormalMapping").
+		WithSampler("albedoMap", "textures/glass.png").WithUniform("specParams", 0.5, 20, 0, 0)
+	built.Samplers[0].DisableMipmaps = true
+	built.Samplers[0].SRGB = true
+	var buf bytes.Buffer
+	if err := built.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeMaterialDesc(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, built) {
+		t.Errorf("round trip gave %+v, want %+v", decoded, built)
+	}
+
+	if _, err := DecodeMaterialDesc(strings.NewReader("<Material>")); !errors.Is(err, ErrInvalidDesc) {
+		t.Errorf("truncated material: %v", err)
+	}
+}
+
+var linkFS = fstest.MapFS{
+	"base.material.xml": {Data: []byte(`<Material>
+	<Sampler name="albedoMap" map="base.png" />
+	<Uniform name="specParams" a="0.1" b="10" />
+	<Uniform name="matDiffuseCol" a="1" b="1" c="1" d="1" />
+</Material>`)},
+	"middle.material.xml": {Data: []byte(`<Material link="base.material.xml">
+	<Sampler name="normalMap" map="middle.png" />
+	<Uniform name="specParams" a="0.2" b="20" />
+</Material>`)},
+	"a.material.xml": {Data: []byte(`<Material link="b.material.xml" />`)},
+	"b.material.xml": {Data: []byte(`<Material link="a.material.xml" />`)},
+}
+
+func TestResolve(t *testing.T) {
+	m := NewMaterialDesc("shaders/model.shader").WithLink("middle.material.xml").
+		WithUniform("specParams", 0.3, 30, 0, 0)
+	resolved, err := m.Resolve(linkFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resolved.Link != "" || resolved.Shader != m.Shader {
+		t.Errorf("resolved %+v", resolved)
+	}
+	if got := resolved.Uniform("specParams").Value; got != [4]float32{0.3, 30, 0, 0} {
+		t.Errorf("specParams = %v, the material's own value should win", got)
+	}
+	if resolved.Uniform("matDiffuseCol") == nil {
+		t.Error("matDiffuseCol from the end of the chain is missing")
+	}
+	if resolved.Sampler("normalMap").Map != "middle.png" ||
+		resolved.Sampler("albedoMap").Map != "base.png" {
+		t.Errorf("samplers %+v", resolved.Samplers)
+	}
+	if m.Link != "middle.material.xml" || len(m.Uniforms) != 1 {
+		t.Errorf("Resolve changed the material: %+v", m)
+	}
+
+	_, err = NewMaterialDesc("").WithLink("a.material.xml").Resolve(linkFS)
+	if !errors.Is(err, ErrInvalidDesc) || !strings.Contains(err.Error(), "cycle") {
+		t.Errorf("cycle: %v", err)
+	}
+	if _, err := NewMaterialDesc("").WithLink("missing.material.xml").Resolve(linkFS); err == nil {
+		t.Error("missing link resolves")
+	}
+}
+
+func TestMaterialValidate(t *testing.T) {
+	shader := &fakeShader{samplers: []string{"albedoMap"}, uniforms: []string{"specParams"}}
+	good := NewMaterialDesc("shaders/model.shader").WithFlag("_F01_Skinning").
+		WithSampler("albedoMap", "a.png").WithUniform("specParams", 0, 0, 0, 0)
+	if err := good.Validate(shader); err != nil {
+		t.Error(err)
+	}
+
+	bad := NewMaterialDesc("shaders/model.shader").WithFlag("_F33_Nope").WithFlag("Skinning").
+		WithSampler("albedoMap", "a.png").WithSampler("albedoMap", "b.png").
+		WithSampler("detailMap", "").WithUniform("specParams", 0, 0, 0, 0).
+		WithUniform("specParams", 1, 0, 0, 0).WithUniform("fresnel", 0, 0, 0, 0)
+	err := bad.Validate(shader)
+	if !errors.Is(err, ErrInvalidDesc) {
+		t.Fatalf("%v is not ErrInvalidDesc", err)
+	}
+	for _, want := range []string{
+		`"_F33_Nope"`, `"Skinning"`, `sampler "albedoMap" is set twice`,
+		`sampler "detailMap" has no texture`, `sampler "detailMap" is not declared`,
+		`uniform "specParams" is set twice`, `uniform "fresnel" is not declared`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("no %s in:\n%v", want, err)
+		}
+	}
+
+	// without a shader only the description itself is checked
+	unknown := NewMaterialDesc("").WithSampler("detailMap", "d.png").WithUniform("fresnel", 0, 0, 0, 0)
+	if err := unknown.Validate(nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,268 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package math
+
+// Mat4 is a 4x4 matrix in column major order, element (row, col) is stored at col*4+row
+type Mat4 [16]float32
+
+func Mat4Identity() Mat4 {
+	return Mat4{
+		1, 0, 0, 0,
+		0, 1, 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	}
+}
+
+func Mat4Translate(t Vec3) Mat4 {
+	m := Mat4Identity()
+	m[12], m[13], m[14] = t.X, t.Y, t.Z
+	return m
+}
+
+func Mat4Scale(s Vec3) Mat4 {
+	m := Mat4Identity()
+	m[0], m[5], m[10] = s.X, s.Y, s.Z
+	return m
+}
+
+func Mat4FromQuat(q Quat) Mat4 {
+	x, y, z, w := q.X, q.Y, q.Z, q.W
+	return Mat4{
+		1 - 2*(y*y+z*z), 2 * (x*y + w*z), 2 * (x*z - w*y), 0,
+		2 * (x*y - w*z), 1 - 2*(x*x+z*z), 2 * (y*z + w*x), 0,
+		2 * (x*z + w*y), 2 * (y*z - w*x), 1 - 2*(x*x+y*y), 0,
+		0, 0, 0, 1,
+	}
+}
+
+// Mat4FromEuler returns the rotation matrix SetTransform uses for the Euler angles rot in degrees
+func Mat4FromEuler(rot Vec3) Mat4 {
+	return Mat4FromQuat(QuatFromEuler(rot))
+}
+
+// Mat4FromTRS returns the matrix SetTransform builds from translation, Euler rotation in degrees
+// and scale
+func Mat4FromTRS(translation Vec3, rotation Vec3, scale Vec3) Mat4 {
+	return Mat4Translate(translation).Mul(Mat4FromEuler(rotation)).Mul(Mat4Scale(scale))
+}
+
+// Mat4Perspective returns the projection SetupCameraView sets up, fovY is in degrees
+func Mat4Perspective(fovY float32, aspect float32, near float32, far float32) Mat4 {
+	top := near * tan(DegToRad(fovY)/2)
+	right := top * aspect
+	return Mat4Frustum(-right, right, -top, top, near, far)
+}
+
+// Mat4Frustum returns a perspective projection for the given near plane rectangle
+func Mat4Frustum(left float32, right float32, bottom float32, top float32, near float32,
+	far float32) Mat4 {
+	return Mat4{
+		2 * near / (right - left), 0, 0, 0,
+		0, 2 * near / (top - bottom), 0, 0,
+		(right + left) / (right - left), (top + bottom) / (top - bottom), -(far + near) / (far - near), -1,
+		0, 0, -2 * far * near / (far - near), 0,
+	}
+}
+
+func (m Mat4) At(row int, col int) float32 {
+	return m[col*4+row]
+}
+
+// Mul returns m * o, the transformation o followed by m
+func (m Mat4) Mul(o Mat4) Mat4 {
+	var r Mat4
+	for col := 0; col < 4; col++ {
+		for row := 0; row < 4; row++ {
+			var sum float32
+			for k := 0; k < 4; k++ {
+				sum += m[k*4+row] * o[col*4+k]
+			}
+			r[col*4+row] = sum
+		}
+	}
+	return r
+}
+
+func (m Mat4) MulVec4(v Vec4) Vec4 {
+	return Vec4{
+		m[0]*v.X + m[4]*v.Y + m[8]*v.Z + m[12]*v.W,
+		m[1]*v.X + m[5]*v.Y + m[9]*v.Z + m[13]*v.W,
+		m[2]*v.X + m[6]*v.Y + m[10]*v.Z + m[14]*v.W,
+		m[3]*v.X + m[7]*v.Y + m[11]*v.Z + m[15]*v.W,
+	}
+}
+
+// MulPoint transforms a position, the translation of m is applied
+func (m Mat4) MulPoint(v Vec3) Vec3 {
+	return m.MulVec4(Vec4{v.X, v.Y, v.Z, 1}).Vec3()
+}
+
+// MulDir transforms a direction, the translation of m is ignored
+func (m Mat4) MulDir(v Vec3) Vec3 {
+	return m.MulVec4(Vec4{v.X, v.Y, v.Z, 0}).Vec3()
+}
+
+func (m Mat4) Transpose() Mat4 {
+	var r Mat4
+	for col := 0; col < 4; col++ {
+		for row := 0; row < 4; row++ {
+			r[row*4+col] = m[col*4+row]
+		}
+	}
+	return r
+}
+
+func (m Mat4) Determinant() float32 {
+	c := m.cofactors()
+	return m[0]*c[0] + m[1]*c[1] + m[2]*c[2] + m[3]*c[3]
+}
+
+// cofactors returns the cofactor matrix of m, in the same layout as m
+func (m Mat4) cofactors() Mat4 {
+	var c Mat4
+	for col := 0; col < 4; col++ {
+		for row := 0; row < 4; row++ {
+			// 3x3 minor without row and col
+			var minor [9]float32
+			i := 0
+			for mc := 0; mc < 4; mc++ {
+				if mc == col {
+					continue
+				}
+				for mr := 0; mr < 4; mr++ {
+					if mr == row {
+						continue
+					}
+					minor[i] = m[mc*4+mr]
+					i++
+				}
+			}
+			det := minor[0]*(minor[4]*minor[8]-minor[7]*minor[5]) -
+				minor[3]*(minor[1]*minor[8]-minor[7]*minor[2]) +
+				minor[6]*(minor[1]*minor[5]-minor[4]*minor[2])
+			if (row+col)%2 == 1 {
+				det = -det
+			}
+			c[col*4+row] = det
+		}
+	}
+	return c
+}
+
+// Inverse returns the inverse of m, ok is false if m can't be inverted
+func (m Mat4) Inverse() (inv Mat4, ok bool) {
+	c := m.cofactors()
+	det := m[0]*c[0] + m[1]*c[1] + m[2]*c[2] + m[3]*c[3]
+	if det == 0 {
+		return Mat4{}, false
+	}
+	// the inverse is the transposed cofactor matrix divided by the determinant
+	adj := c.Transpose()
+	for i := range adj {
+		inv[i] = adj[i] / det
+	}
+	return inv, true
+}
+
+// Translation returns the translation part of m
+func (m Mat4) Translation() Vec3 {
+	return Vec3{m[12], m[13], m[14]}
+}
+
+// Decompose splits m into the translation, Euler rotation in degrees and scale SetTransform
+// expects.  It assumes m has no shear or projection, like the matrices returned by TransMats.
+func (m Mat4) Decompose() (translation Vec3, rotation Vec3, scale Vec3) {
+	translation = m.Translation()
+	r, scale, ok := m.rotation()
+	if !ok {
+		return translation, Vec3{}, scale
+	}
+
+	// the rotation is Ry * Rx * Rz, so element (1, 2) is -sin(x) and the elements next to it
+	// are scaled by cos(x), which is never negative for x in [-90, 90]
+	cosX := sqrt(r[0][2]*r[0][2] + r[2][2]*r[2][2])
+	rotation.X = atan2(-r[1][2], cosX)
+	if cosX < 1e-6 {
+		// gimbal lock, only the sum of y and z matters so y is pinned to 0
+		rotation.Z = atan2(-r[0][1], r[0][0])
+	} else {
+		rotation.Y = atan2(r[0][2], r[2][2])
+		rotation.Z = atan2(r[1][0], r[1][1])
+	}
+	rotation = Vec3{RadToDeg(rotation.X), RadToDeg(rotation.Y), RadToDeg(rotation.Z)}
+	return translation, rotation, scale
+}
+
+// rotation returns the rotation part of m as rows, with the scale divided out.  ok is false if
+// an axis has zero scale.
+func (m Mat4) rotation() (r [3][3]float32, scale Vec3, ok bool) {
+	scale = Vec3{
+		Vec3{m[0], m[1], m[2]}.Len(),
+		Vec3{m[4], m[5], m[6]}.Len(),
+		Vec3{m[8], m[9], m[10]}.Len(),
+	}
+	if scale.X == 0 || scale.Y == 0 || scale.Z == 0 {
+		return r, scale, false
+	}
+	// a negative determinant means one axis is mirrored, flip x to account for it
+	if m.Determinant() < 0 {
+		scale.X = -scale.X
+	}
+	s := [3]float32{scale.X, scale.Y, scale.Z}
+	for row := range r {
+		for col := range r[row] {
+			r[row][col] = m.At(row, col) / s[col]
+		}
+	}
+	return r, scale, true
+}
+
+// Euler returns the rotation part of m as Euler angles in degrees
+func (m Mat4) Euler() Vec3 {
+	_, rotation, _ := m.Decompose()
+	return rotation
+}
+
+// Quat returns the rotation part of m as a quaternion
+func (m Mat4) Quat() Quat {
+	r, _, ok := m.rotation()
+	if !ok {
+		return QuatIdentity()
+	}
+	// start from the largest component to keep the division well conditioned
+	var q Quat
+	switch trace := r[0][0] + r[1][1] + r[2][2]; {
+	case trace > 0:
+		s := 2 * sqrt(1+trace)
+		q = Quat{(r[2][1] - r[1][2]) / s, (r[0][2] - r[2][0]) / s, (r[1][0] - r[0][1]) / s, s / 4}
+	case r[0][0] > r[1][1] && r[0][0] > r[2][2]:
+		s := 2 * sqrt(1+r[0][0]-r[1][1]-r[2][2])
+		q = Quat{s / 4, (r[0][1] + r[1][0]) / s, (r[0][2] + r[2][0]) / s, (r[2][1] - r[1][2]) / s}
+	case r[1][1] > r[2][2]:
+		s := 2 * sqrt(1+r[1][1]-r[0][0]-r[2][2])
+		q = Quat{(r[0][1] + r[1][0]) / s, s / 4, (r[1][2] + r[2][1]) / s, (r[0][2] - r[2][0]) / s}
+	default:
+		s := 2 * sqrt(1+r[2][2]-r[0][0]-r[1][1])
+		q = Quat{(r[0][2] + r[2][0]) / s, (r[1][2] + r[2][1]) / s, s / 4, (r[1][0] - r[0][1]) / s}
+	}
+	return q.Normalize()
+}
@@ -0,0 +1,69 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+// Package math provides the vector, quaternion and matrix types needed to work with Horde3D
+// transformations.  Vec3, Quat and Mat4 have the same layout as the horde3d types of the same
+// name and can be converted to and from them directly.
+//
+// Matrices are stored in column major order like the [16]float32 used by TransMats and
+// SetNodeTransMat, and Euler angles are in degrees, applied in Horde3D's order (Z, then X, then Y
+// about the parent axes), matching SetTransform.
+package math
+
+import stdmath "math"
+
+// DegToRad converts an angle from degrees to radians
+func DegToRad(deg float32) float32 {
+	return deg * (stdmath.Pi / 180)
+}
+
+// RadToDeg converts an angle from radians to degrees
+func RadToDeg(rad float32) float32 {
+	return rad * (180 / stdmath.Pi)
+}
+
+func sin(rad float32) float32 {
+	return float32(stdmath.Sin(float64(rad)))
+}
+
+func cos(rad float32) float32 {
+	return float32(stdmath.Cos(float64(rad)))
+}
+
+func sqrt(v float32) float32 {
+	return float32(stdmath.Sqrt(float64(v)))
+}
+
+func atan2(y float32, x float32) float32 {
+	return float32(stdmath.Atan2(float64(y), float64(x)))
+}
+
+func acos(v float32) float32 {
+	if v > 1 {
+		v = 1
+	} else if v < -1 {
+		v = -1
+	}
+	return float32(stdmath.Acos(float64(v)))
+}
+
+func tan(rad float32) float32 {
+	return float32(stdmath.Tan(float64(rad)))
+}
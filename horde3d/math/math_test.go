@@ -0,0 +1,186 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package math
+
+import (
+	stdmath "math"
+	"testing"
+)
+
+const epsilon = 1e-4
+
+func near(a, b float32) bool {
+	return stdmath.Abs(float64(a-b)) < epsilon
+}
+
+func nearVec3(a, b Vec3) bool {
+	return near(a.X, b.X) && near(a.Y, b.Y) && near(a.Z, b.Z)
+}
+
+func nearMat4(a, b Mat4) bool {
+	for i := range a {
+		if !near(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// eulerMatrix builds Ry * Rx * Rz element by element, independently of the quaternion path
+func eulerMatrix(rot Vec3) Mat4 {
+	x, y, z := float64(DegToRad(rot.X)), float64(DegToRad(rot.Y)), float64(DegToRad(rot.Z))
+	cx, sx := stdmath.Cos(x), stdmath.Sin(x)
+	cy, sy := stdmath.Cos(y), stdmath.Sin(y)
+	cz, sz := stdmath.Cos(z), stdmath.Sin(z)
+	rows := [3][3]float64{
+		{cy*cz + sx*sy*sz, cz*sx*sy - cy*sz, cx * sy},
+		{cx * sz, cx * cz, -sx},
+		{cy*sx*sz - cz*sy, cy*cz*sx + sy*sz, cx * cy},
+	}
+	m := Mat4Identity()
+	for r := range rows {
+		for c := range rows[r] {
+			m[c*4+r] = float32(rows[r][c])
+		}
+	}
+	return m
+}
+
+var rotations = []Vec3{
+	{0, 0, 0}, {10, 20, 30}, {-60, 0, 0}, {0, 180, 0}, {45, -120, 170},
+	{88, 30, 10}, {89.5, 45, 0}, {89.99, -70, 20}, {-88, 30, 10}, {-89.5, 120, -45},
+}
+
+func TestMat4FromEuler(t *testing.T) {
+	for _, r := range rotations {
+		if got, want := Mat4FromEuler(r), eulerMatrix(r); !nearMat4(got, want) {
+			t.Errorf("Mat4FromEuler(%v) = %v, want %v", r, got, want)
+		}
+	}
+	if v := Mat4FromEuler(Vec3{Y: 90}).MulDir(Vec3{X: 1}); !nearVec3(v, Vec3{Z: -1}) {
+		t.Errorf("rotating x by 90 degrees about y gives %v", v)
+	}
+	if v := Mat4FromEuler(Vec3{X: 90}).MulDir(Vec3{Y: 1}); !nearVec3(v, Vec3{Z: 1}) {
+		t.Errorf("rotating y by 90 degrees about x gives %v", v)
+	}
+}
+
+func TestMat4FromTRS(t *testing.T) {
+	m := Mat4FromTRS(Vec3{1, 2, 3}, Vec3{Y: 90}, Vec3{2, 2, 2})
+	want := Mat4{
+		0, 0, -2, 0,
+		0, 2, 0, 0,
+		2, 0, 0, 0,
+		1, 2, 3, 1,
+	}
+	if !nearMat4(m, want) {
+		t.Errorf("Mat4FromTRS = %v, want %v", m, want)
+	}
+	if p := m.MulPoint(Vec3{X: 1}); !nearVec3(p, Vec3{1, 2, 1}) {
+		t.Errorf("MulPoint = %v", p)
+	}
+}
+
+func TestMat4Inverse(t *testing.T) {
+	m := Mat4{
+		2, 0, 0, 0,
+		0, 4, 0, 0,
+		0, 0, 8, 0,
+		1, 2, 3, 1,
+	}
+	want := Mat4{
+		0.5, 0, 0, 0,
+		0, 0.25, 0, 0,
+		0, 0, 0.125, 0,
+		-0.5, -0.5, -0.375, 1,
+	}
+	if inv, ok := m.Inverse(); !ok || !nearMat4(inv, want) {
+		t.Errorf("Inverse = %v, %v, want %v", inv, ok, want)
+	}
+
+	for _, r := range rotations {
+		m := Mat4FromTRS(Vec3{1, -2, 3}, r, Vec3{0.5, 2, 3})
+		inv, ok := m.Inverse()
+		if !ok || !nearMat4(inv.Mul(m), Mat4Identity()) {
+			t.Errorf("inverse of %v times itself is %v", r, inv.Mul(m))
+		}
+	}
+	if _, ok := Mat4Scale(Vec3{1, 0, 1}).Inverse(); ok {
+		t.Error("singular matrix was inverted")
+	}
+}
+
+func TestMat4Decompose(t *testing.T) {
+	for _, r := range rotations {
+		translation, scale := Vec3{1, -2, 3}, Vec3{0.1, 2, 3}
+		m := Mat4FromTRS(translation, r, scale)
+		t2, r2, s2 := m.Decompose()
+		if !nearVec3(t2, translation) || !nearVec3(s2, scale) {
+			t.Errorf("Decompose(%v) translation %v scale %v", r, t2, s2)
+		}
+		if rebuilt := Mat4FromTRS(t2, r2, s2); !nearMat4(rebuilt, m) {
+			t.Errorf("Decompose(%v) = %v, which rebuilds to %v, want %v", r, r2, rebuilt, m)
+		}
+	}
+
+	// with the pitch at exactly 90 degrees only the sum of y and z is known
+	m := Mat4FromEuler(Vec3{90, 30, 10})
+	_, r, _ := m.Decompose()
+	if !near(r.X, 90) || r.Y != 0 || !nearMat4(Mat4FromEuler(r), m) {
+		t.Errorf("gimbal locked rotation decomposes to %v", r)
+	}
+
+	_, r, s := Mat4FromTRS(Vec3{}, Vec3{10, 20, 30}, Vec3{-1, 1, 1}).Decompose()
+	if !nearMat4(Mat4FromTRS(Vec3{}, r, s), Mat4FromTRS(Vec3{}, Vec3{10, 20, 30}, Vec3{-1, 1, 1})) {
+		t.Errorf("mirrored matrix decomposes to %v %v", r, s)
+	}
+}
+
+func TestMat4Quat(t *testing.T) {
+	for _, r := range rotations {
+		q := QuatFromEuler(r)
+		got := Mat4FromTRS(Vec3{}, r, Vec3{2, 3, 4}).Quat()
+		if d := q.Dot(got); !near(float32(stdmath.Abs(float64(d))), 1) {
+			t.Errorf("Quat of %v = %v, want %v", r, got, q)
+		}
+		v := Vec3{1, 2, 3}
+		if !nearVec3(q.Rotate(v), eulerMatrix(r).MulDir(v)) {
+			t.Errorf("QuatFromEuler(%v) rotates %v to %v", r, v, q.Rotate(v))
+		}
+		if back := QuatFromEuler(q.Euler()); !nearVec3(back.Rotate(v), q.Rotate(v)) {
+			t.Errorf("Euler of %v = %v", r, q.Euler())
+		}
+	}
+}
+
+func TestQuatSlerp(t *testing.T) {
+	q := QuatIdentity().Slerp(QuatFromAxisAngle(Vec3{Y: 1}, 90), 0.5)
+	if !nearVec3(q.Euler(), Vec3{Y: 45}) {
+		t.Errorf("halfway slerp = %v", q.Euler())
+	}
+}
+
+func TestMat4Perspective(t *testing.T) {
+	v := Mat4Perspective(90, 1, 1, 100).MulVec4(Vec4{0, 1, -1, 1})
+	if !near(v.Y/v.W, 1) || !near(v.Z/v.W, -1) {
+		t.Errorf("top of the near plane projects to %v", v)
+	}
+}
@@ -0,0 +1,112 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package math
+
+type Quat struct {
+	X, Y, Z, W float32
+}
+
+func QuatIdentity() Quat {
+	return Quat{W: 1}
+}
+
+// QuatFromAxisAngle returns a rotation of deg degrees about axis
+func QuatFromAxisAngle(axis Vec3, deg float32) Quat {
+	half := DegToRad(deg) / 2
+	a := axis.Normalize().Mul(sin(half))
+	return Quat{a.X, a.Y, a.Z, cos(half)}
+}
+
+// QuatFromEuler returns the rotation SetTransform uses for the Euler angles rot in degrees
+func QuatFromEuler(rot Vec3) Quat {
+	x := QuatFromAxisAngle(Vec3{1, 0, 0}, rot.X)
+	y := QuatFromAxisAngle(Vec3{0, 1, 0}, rot.Y)
+	z := QuatFromAxisAngle(Vec3{0, 0, 1}, rot.Z)
+	return y.Mul(x).Mul(z)
+}
+
+// Mul returns the rotation o followed by q
+func (q Quat) Mul(o Quat) Quat {
+	return Quat{
+		q.Y*o.Z - q.Z*o.Y + o.X*q.W + q.X*o.W,
+		q.Z*o.X - q.X*o.Z + o.Y*q.W + q.Y*o.W,
+		q.X*o.Y - q.Y*o.X + o.Z*q.W + q.Z*o.W,
+		q.W*o.W - (q.X*o.X + q.Y*o.Y + q.Z*o.Z),
+	}
+}
+
+func (q Quat) Dot(o Quat) float32 {
+	return q.X*o.X + q.Y*o.Y + q.Z*o.Z + q.W*o.W
+}
+
+func (q Quat) Len() float32 {
+	return sqrt(q.Dot(q))
+}
+
+func (q Quat) Normalize() Quat {
+	l := q.Len()
+	if l == 0 {
+		return QuatIdentity()
+	}
+	return Quat{q.X / l, q.Y / l, q.Z / l, q.W / l}
+}
+
+// Conjugate returns the inverse rotation of a unit quaternion
+func (q Quat) Conjugate() Quat {
+	return Quat{-q.X, -q.Y, -q.Z, q.W}
+}
+
+func (q Quat) Rotate(v Vec3) Vec3 {
+	r := q.Mul(Quat{v.X, v.Y, v.Z, 0}).Mul(q.Conjugate())
+	return Vec3{r.X, r.Y, r.Z}
+}
+
+// Slerp interpolates spherically between q (t = 0) and o (t = 1) along the shorter arc
+func (q Quat) Slerp(o Quat, t float32) Quat {
+	cosTheta := q.Dot(o)
+	if cosTheta < 0 {
+		o = Quat{-o.X, -o.Y, -o.Z, -o.W}
+		cosTheta = -cosTheta
+	}
+
+	var scale0, scale1 float32
+	if 1-cosTheta > 0.001 {
+		theta := acos(cosTheta)
+		sinTheta := sin(theta)
+		scale0 = sin((1-t)*theta) / sinTheta
+		scale1 = sin(t*theta) / sinTheta
+	} else {
+		// nearly the same rotation, fall back to linear interpolation
+		scale0 = 1 - t
+		scale1 = t
+	}
+	return Quat{
+		q.X*scale0 + o.X*scale1,
+		q.Y*scale0 + o.Y*scale1,
+		q.Z*scale0 + o.Z*scale1,
+		q.W*scale0 + o.W*scale1,
+	}.Normalize()
+}
+
+// Euler returns the Euler angles in degrees that SetTransform needs for this rotation
+func (q Quat) Euler() Vec3 {
+	return Mat4FromQuat(q).Euler()
+}
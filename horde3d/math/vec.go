@@ -0,0 +1,121 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package math
+
+type Vec2 struct {
+	X, Y float32
+}
+
+func (v Vec2) Add(o Vec2) Vec2 {
+	return Vec2{v.X + o.X, v.Y + o.Y}
+}
+
+func (v Vec2) Sub(o Vec2) Vec2 {
+	return Vec2{v.X - o.X, v.Y - o.Y}
+}
+
+func (v Vec2) Mul(s float32) Vec2 {
+	return Vec2{v.X * s, v.Y * s}
+}
+
+func (v Vec2) Dot(o Vec2) float32 {
+	return v.X*o.X + v.Y*o.Y
+}
+
+func (v Vec2) Len() float32 {
+	return sqrt(v.Dot(v))
+}
+
+// Normalize returns v scaled to length 1, a zero vector is returned unchanged
+func (v Vec2) Normalize() Vec2 {
+	l := v.Len()
+	if l == 0 {
+		return v
+	}
+	return v.Mul(1 / l)
+}
+
+type Vec3 struct {
+	X, Y, Z float32
+}
+
+func (v Vec3) Add(o Vec3) Vec3 {
+	return Vec3{v.X + o.X, v.Y + o.Y, v.Z + o.Z}
+}
+
+func (v Vec3) Sub(o Vec3) Vec3 {
+	return Vec3{v.X - o.X, v.Y - o.Y, v.Z - o.Z}
+}
+
+func (v Vec3) Mul(s float32) Vec3 {
+	return Vec3{v.X * s, v.Y * s, v.Z * s}
+}
+
+func (v Vec3) Dot(o Vec3) float32 {
+	return v.X*o.X + v.Y*o.Y + v.Z*o.Z
+}
+
+func (v Vec3) Cross(o Vec3) Vec3 {
+	return Vec3{v.Y*o.Z - v.Z*o.Y, v.Z*o.X - v.X*o.Z, v.X*o.Y - v.Y*o.X}
+}
+
+func (v Vec3) Len() float32 {
+	return sqrt(v.Dot(v))
+}
+
+// Normalize returns v scaled to length 1, a zero vector is returned unchanged
+func (v Vec3) Normalize() Vec3 {
+	l := v.Len()
+	if l == 0 {
+		return v
+	}
+	return v.Mul(1 / l)
+}
+
+// Lerp interpolates linearly between v (t = 0) and o (t = 1)
+func (v Vec3) Lerp(o Vec3, t float32) Vec3 {
+	return v.Add(o.Sub(v).Mul(t))
+}
+
+type Vec4 struct {
+	X, Y, Z, W float32
+}
+
+func (v Vec4) Add(o Vec4) Vec4 {
+	return Vec4{v.X + o.X, v.Y + o.Y, v.Z + o.Z, v.W + o.W}
+}
+
+func (v Vec4) Sub(o Vec4) Vec4 {
+	return Vec4{v.X - o.X, v.Y - o.Y, v.Z - o.Z, v.W - o.W}
+}
+
+func (v Vec4) Mul(s float32) Vec4 {
+	return Vec4{v.X * s, v.Y * s, v.Z * s, v.W * s}
+}
+
+func (v Vec4) Dot(o Vec4) float32 {
+	return v.X*o.X + v.Y*o.Y + v.Z*o.Z + v.W*o.W
+}
+
+// Vec3 drops the w component
+func (v Vec4) Vec3() Vec3 {
+	return Vec3{v.X, v.Y, v.Z}
+}
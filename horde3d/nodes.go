@@ -0,0 +1,404 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import "fmt"
+
+type CameraNode struct{ H3DNode }
+type LightNode struct{ H3DNode }
+type ModelNode struct{ H3DNode }
+type MeshNode struct{ H3DNode }
+type JointNode struct{ H3DNode }
+type EmitterNode struct{ H3DNode }
+
+func checkNodeType(node H3DNode, nodeType int) error {
+	if node == 0 {
+		return ErrInvalidHandle
+	}
+	if t := node.Type(); t != nodeType {
+		return fmt.Errorf("%w: node %s is a %s node, not a %s node", ErrWrongType,
+			node.NodeParamStr(NodeParams_NameStr), nodeTypeName(t), nodeTypeName(nodeType))
+	}
+	return nil
+}
+
+func nodeTypeName(nodeType int) string {
+	switch nodeType {
+	case NodeTypes_Group:
+		return "Group"
+	case NodeTypes_Model:
+		return "Model"
+	case NodeTypes_Mesh:
+		return "Mesh"
+	case NodeTypes_Joint:
+		return "Joint"
+	case NodeTypes_Light:
+		return "Light"
+	case NodeTypes_Camera:
+		return "Camera"
+	case NodeTypes_Emitter:
+		return "Emitter"
+	}
+	return "Undefined"
+}
+
+func AsCameraNode(node H3DNode) (CameraNode, error) {
+	return CameraNode{node}, checkNodeType(node, NodeTypes_Camera)
+}
+
+func AsLightNode(node H3DNode) (LightNode, error) {
+	return LightNode{node}, checkNodeType(node, NodeTypes_Light)
+}
+
+func AsModelNode(node H3DNode) (ModelNode, error) {
+	return ModelNode{node}, checkNodeType(node, NodeTypes_Model)
+}
+
+func AsMeshNode(node H3DNode) (MeshNode, error) {
+	return MeshNode{node}, checkNodeType(node, NodeTypes_Mesh)
+}
+
+func AsJointNode(node H3DNode) (JointNode, error) {
+	return JointNode{node}, checkNodeType(node, NodeTypes_Joint)
+}
+
+func AsEmitterNode(node H3DNode) (EmitterNode, error) {
+	return EmitterNode{node}, checkNodeType(node, NodeTypes_Emitter)
+}
+
+// Camera
+
+func (c CameraNode) Pipeline() Pipeline {
+	return Pipeline{resource{H3DRes(c.NodeParamI(Camera_PipeResI))}}
+}
+
+func (c CameraNode) SetPipeline(pipeline Pipeline) {
+	c.SetNodeParamI(Camera_PipeResI, int(pipeline.res))
+}
+
+func (c CameraNode) Viewport() (x int, y int, width int, height int) {
+	return c.NodeParamI(Camera_ViewportXI), c.NodeParamI(Camera_ViewportYI),
+		c.NodeParamI(Camera_ViewportWidthI), c.NodeParamI(Camera_ViewportHeightI)
+}
+
+func (c CameraNode) SetViewport(x int, y int, width int, height int) {
+	c.SetNodeParamI(Camera_ViewportXI, x)
+	c.SetNodeParamI(Camera_ViewportYI, y)
+	c.SetNodeParamI(Camera_ViewportWidthI, width)
+	c.SetNodeParamI(Camera_ViewportHeightI, height)
+}
+
+// SetupView sets up a symmetric perspective frustum, fov is the vertical field of view in degrees
+func (c CameraNode) SetupView(fov float32, aspect float32, nearDist float32, farDist float32) {
+	SetupCameraView(c.H3DNode, fov, aspect, nearDist, farDist)
+}
+
+// Frustum returns the planes of the view frustum, left, right, bottom and top are relative to the
+// near plane center
+func (c CameraNode) Frustum() (left float32, right float32, bottom float32, top float32,
+	near float32, far float32) {
+	return c.NodeParamF(Camera_LeftPlaneF, 0), c.NodeParamF(Camera_RightPlaneF, 0),
+		c.NodeParamF(Camera_BottomPlaneF, 0), c.NodeParamF(Camera_TopPlaneF, 0),
+		c.NodeParamF(Camera_NearPlaneF, 0), c.NodeParamF(Camera_FarPlaneF, 0)
+}
+
+func (c CameraNode) SetFrustum(left float32, right float32, bottom float32, top float32,
+	near float32, far float32) {
+	c.SetNodeParamF(Camera_LeftPlaneF, 0, left)
+	c.SetNodeParamF(Camera_RightPlaneF, 0, right)
+	c.SetNodeParamF(Camera_BottomPlaneF, 0, bottom)
+	c.SetNodeParamF(Camera_TopPlaneF, 0, top)
+	c.SetNodeParamF(Camera_NearPlaneF, 0, near)
+	c.SetNodeParamF(Camera_FarPlaneF, 0, far)
+}
+
+func (c CameraNode) Orthographic() bool {
+	return c.NodeParamI(Camera_OrthoI) != 0
+}
+
+func (c CameraNode) SetOrthographic(ortho bool) {
+	c.SetNodeParamI(Camera_OrthoI, int(Int[ortho]))
+}
+
+func (c CameraNode) OcclusionCulling() bool {
+	return c.NodeParamI(Camera_OccCullingI) != 0
+}
+
+func (c CameraNode) SetOcclusionCulling(enabled bool) {
+	c.SetNodeParamI(Camera_OccCullingI, int(Int[enabled]))
+}
+
+// OutputTexture returns the texture the camera renders to and the stereo buffer index, a zero
+// texture means the main framebuffer
+func (c CameraNode) OutputTexture() (Texture, int) {
+	return Texture{resource{H3DRes(c.NodeParamI(Camera_OutTexResI))}}, c.NodeParamI(Camera_OutBufIndexI)
+}
+
+func (c CameraNode) SetOutputTexture(texture Texture, bufIndex int) {
+	c.SetNodeParamI(Camera_OutTexResI, int(texture.res))
+	c.SetNodeParamI(Camera_OutBufIndexI, bufIndex)
+}
+
+// Light
+
+func (l LightNode) Material() Material {
+	return Material{resource{H3DRes(l.NodeParamI(Light_MatResI))}}
+}
+
+func (l LightNode) SetMaterial(material Material) {
+	l.SetNodeParamI(Light_MatResI, int(material.res))
+}
+
+func (l LightNode) Radius() float32 {
+	return l.NodeParamF(Light_RadiusF, 0)
+}
+
+func (l LightNode) SetRadius(radius float32) {
+	l.SetNodeParamF(Light_RadiusF, 0, radius)
+}
+
+func (l LightNode) Fov() float32 {
+	return l.NodeParamF(Light_FovF, 0)
+}
+
+func (l LightNode) SetFov(fov float32) {
+	l.SetNodeParamF(Light_FovF, 0, fov)
+}
+
+func (l LightNode) Color() (r float32, g float32, b float32) {
+	return l.NodeParamF(Light_ColorF3, 0), l.NodeParamF(Light_ColorF3, 1), l.NodeParamF(Light_ColorF3, 2)
+}
+
+func (l LightNode) SetColor(r float32, g float32, b float32) {
+	l.SetNodeParamF(Light_ColorF3, 0, r)
+	l.SetNodeParamF(Light_ColorF3, 1, g)
+	l.SetNodeParamF(Light_ColorF3, 2, b)
+}
+
+func (l LightNode) ColorMultiplier() float32 {
+	return l.NodeParamF(Light_ColorMultiplierF, 0)
+}
+
+func (l LightNode) SetColorMultiplier(multiplier float32) {
+	l.SetNodeParamF(Light_ColorMultiplierF, 0, multiplier)
+}
+
+// ShadowMaps returns the number of shadow maps, the shadow map bias and the split lambda used
+// for Parallel Split Shadow Maps
+func (l LightNode) ShadowMaps() (count int, bias float32, lambda float32) {
+	return l.NodeParamI(Light_ShadowMapCountI), l.NodeParamF(Light_ShadowMapBiasF, 0),
+		l.NodeParamF(Light_ShadowSplitLambdaF, 0)
+}
+
+// SetShadowMaps sets the number of shadow maps (0 to 4), the shadow map bias and the split
+// lambda used for Parallel Split Shadow Maps
+func (l LightNode) SetShadowMaps(count int, bias float32, lambda float32) error {
+	if count < 0 || count > 4 {
+		return fmt.Errorf("%w: shadow map count %d is not between 0 and 4", ErrInvalidOption, count)
+	}
+	l.SetNodeParamI(Light_ShadowMapCountI, count)
+	l.SetNodeParamF(Light_ShadowMapBiasF, 0, bias)
+	l.SetNodeParamF(Light_ShadowSplitLambdaF, 0, lambda)
+	return nil
+}
+
+func (l LightNode) LightingContext() string {
+	return l.NodeParamStr(Light_LightingContextStr)
+}
+
+func (l LightNode) SetLightingContext(context string) {
+	l.SetNodeParamStr(Light_LightingContextStr, context)
+}
+
+func (l LightNode) ShadowContext() string {
+	return l.NodeParamStr(Light_ShadowContextStr)
+}
+
+func (l LightNode) SetShadowContext(context string) {
+	l.SetNodeParamStr(Light_ShadowContextStr, context)
+}
+
+// Model
+
+func (m ModelNode) Geometry() Geometry {
+	return Geometry{resource{H3DRes(m.NodeParamI(Model_GeoResI))}}
+}
+
+func (m ModelNode) SetGeometry(geometry Geometry) {
+	m.SetNodeParamI(Model_GeoResI, int(geometry.res))
+}
+
+func (m ModelNode) SoftwareSkinning() bool {
+	return m.NodeParamI(Model_SWSkinningI) != 0
+}
+
+func (m ModelNode) SetSoftwareSkinning(enabled bool) {
+	m.SetNodeParamI(Model_SWSkinningI, int(Int[enabled]))
+}
+
+func (m ModelNode) LODDistances() [4]float32 {
+	return [4]float32{m.NodeParamF(Model_LodDist1F, 0), m.NodeParamF(Model_LodDist2F, 0),
+		m.NodeParamF(Model_LodDist3F, 0), m.NodeParamF(Model_LodDist4F, 0)}
+}
+
+// SetLODDistances sets the camera distances from which on LOD1, LOD2, ... are used.  Up to
+// four positive, non decreasing distances can be given.
+func (m ModelNode) SetLODDistances(distances ...float32) error {
+	if len(distances) > 4 {
+		return fmt.Errorf("%w: %d LOD distances given, at most 4 are supported", ErrInvalidOption,
+			len(distances))
+	}
+	for i, d := range distances {
+		if d <= 0 || (i > 0 && d < distances[i-1]) {
+			return fmt.Errorf("%w: LOD distances must be positive and non decreasing", ErrInvalidOption)
+		}
+	}
+	params := [4]int{Model_LodDist1F, Model_LodDist2F, Model_LodDist3F, Model_LodDist4F}
+	for i, d := range distances {
+		m.SetNodeParamF(params[i], 0, d)
+	}
+	return nil
+}
+
+func (m ModelNode) SetupAnimStage(stage int, animation Animation, layer int, startNode string,
+	additive bool) {
+	SetupModelAnimStage(m.H3DNode, stage, animation.res, layer, startNode, additive)
+}
+
+func (m ModelNode) SetAnimParams(stage int, time float32, weight float32) {
+	SetModelAnimParams(m.H3DNode, stage, time, weight)
+}
+
+func (m ModelNode) SetMorpher(target string, weight float32) error {
+	return SetModelMorpherErr(m.H3DNode, target, weight)
+}
+
+// Mesh
+
+func (m MeshNode) Material() Material {
+	return Material{resource{H3DRes(m.NodeParamI(Mesh_MatResI))}}
+}
+
+func (m MeshNode) SetMaterial(material Material) {
+	m.SetNodeParamI(Mesh_MatResI, int(material.res))
+}
+
+// Batch returns the first triangle index and the number of indices used to draw the mesh
+func (m MeshNode) Batch() (start int, count int) {
+	return m.NodeParamI(Mesh_BatchStartI), m.NodeParamI(Mesh_BatchCountI)
+}
+
+// VertexRange returns the first and last vertex of the mesh in the geometry of its model
+func (m MeshNode) VertexRange() (start int, end int) {
+	return m.NodeParamI(Mesh_VertRStartI), m.NodeParamI(Mesh_VertREndI)
+}
+
+func (m MeshNode) LODLevel() int {
+	return m.NodeParamI(Mesh_LodLevelI)
+}
+
+func (m MeshNode) SetLODLevel(level int) {
+	m.SetNodeParamI(Mesh_LodLevelI, level)
+}
+
+// Joint
+
+func (j JointNode) JointIndex() int {
+	return j.NodeParamI(Joint_JointIndexI)
+}
+
+// Emitter
+
+func (e EmitterNode) Material() Material {
+	return Material{resource{H3DRes(e.NodeParamI(Emitter_MatResI))}}
+}
+
+func (e EmitterNode) SetMaterial(material Material) {
+	e.SetNodeParamI(Emitter_MatResI, int(material.res))
+}
+
+func (e EmitterNode) Effect() ParticleEffect {
+	return ParticleEffect{resource{H3DRes(e.NodeParamI(Emitter_PartEffResI))}}
+}
+
+func (e EmitterNode) SetEffect(effect ParticleEffect) {
+	e.SetNodeParamI(Emitter_PartEffResI, int(effect.res))
+}
+
+func (e EmitterNode) MaxCount() int {
+	return e.NodeParamI(Emitter_MaxCountI)
+}
+
+func (e EmitterNode) SetMaxCount(count int) {
+	e.SetNodeParamI(Emitter_MaxCountI, count)
+}
+
+// RespawnCount returns how often a particle is recreated after dying, -1 means forever
+func (e EmitterNode) RespawnCount() int {
+	return e.NodeParamI(Emitter_RespawnCountI)
+}
+
+func (e EmitterNode) SetRespawnCount(count int) {
+	e.SetNodeParamI(Emitter_RespawnCountI, count)
+}
+
+func (e EmitterNode) Delay() float32 {
+	return e.NodeParamF(Emitter_DelayF, 0)
+}
+
+func (e EmitterNode) SetDelay(seconds float32) {
+	e.SetNodeParamF(Emitter_DelayF, 0, seconds)
+}
+
+func (e EmitterNode) EmissionRate() float32 {
+	return e.NodeParamF(Emitter_EmissionRateF, 0)
+}
+
+func (e EmitterNode) SetEmissionRate(rate float32) {
+	e.SetNodeParamF(Emitter_EmissionRateF, 0, rate)
+}
+
+func (e EmitterNode) SpreadAngle() float32 {
+	return e.NodeParamF(Emitter_SpreadAngleF, 0)
+}
+
+func (e EmitterNode) SetSpreadAngle(angle float32) {
+	e.SetNodeParamF(Emitter_SpreadAngleF, 0, angle)
+}
+
+func (e EmitterNode) Force() Vec3 {
+	return Vec3{X: e.NodeParamF(Emitter_ForceF3, 0), Y: e.NodeParamF(Emitter_ForceF3, 1),
+		Z: e.NodeParamF(Emitter_ForceF3, 2)}
+}
+
+func (e EmitterNode) SetForce(force Vec3) {
+	e.SetNodeParamF(Emitter_ForceF3, 0, force.X)
+	e.SetNodeParamF(Emitter_ForceF3, 1, force.Y)
+	e.SetNodeParamF(Emitter_ForceF3, 2, force.Z)
+}
+
+func (e EmitterNode) Update(timeDelta float32) {
+	UpdateEmitter(e.H3DNode, timeDelta)
+}
+
+func (e EmitterNode) HasFinished() bool {
+	return HasEmitterFinished(e.H3DNode)
+}
@@ -0,0 +1,185 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"bitbucket.org/tshannon/gohorde/horde3d/format/geo"
+	"bitbucket.org/tshannon/gohorde/horde3d/format/obj"
+	"bitbucket.org/tshannon/gohorde/horde3d/format/scene"
+)
+
+// OBJImport is a Wavefront OBJ model converted to Horde3D resources: a geometry, a material for
+// every material of the model and a scene graph with a Mesh for each of them.  The resource
+// names are made from Dir and Name, like models/crate/crate.geo.
+type OBJImport struct {
+	Dir       string
+	Name      string
+	Geometry  *geo.Geometry
+	Materials map[string]*MaterialDesc // by resource name
+	Scene     *scene.Model
+}
+
+// ImportOBJ reads the OBJ file name and its material libraries from fsys, which should be the
+// content directory so texture names are valid resource names.  The resources are named in the
+// resource directory dir.
+//
+// Materials use shaders/model.shader with the diffuse map as albedoMap.  A bump map is used as
+// normalMap, switching on _F02_NormalMapping, and the specular color and exponent set
+// specParams.
+func ImportOBJ(fsys fs.FS, name string, dir string) (*OBJImport, error) {
+	f, err := obj.Load(fsys, name)
+	if err != nil {
+		return nil, err
+	}
+	g, batches, err := f.Geometry()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	base := strings.TrimSuffix(path.Base(name), path.Ext(name))
+	m := &OBJImport{
+		Dir:       dir,
+		Name:      base,
+		Geometry:  g,
+		Materials: make(map[string]*MaterialDesc),
+	}
+	m.Scene = scene.NewModel(base, m.resourceName(".geo"))
+	// OBJ material names that differ only in characters a file name can't have get a number
+	fileNames := make(map[string]string)
+	taken := make(map[string]bool)
+	for _, b := range batches {
+		matName, ok := fileNames[b.Material]
+		if !ok {
+			matName = resourceFileName(b.Material)
+			for n := 2; taken[matName]; n++ {
+				matName = resourceFileName(b.Material) + "_" + strconv.Itoa(n)
+			}
+			fileNames[b.Material] = matName
+			taken[matName] = true
+		}
+		resName := m.resourceName("_" + matName + ".material.xml")
+		m.Materials[resName] = objMaterial(f.Material(b.Material))
+
+		mesh := scene.NewMesh(matName, resName)
+		mesh.BatchStart, mesh.BatchCount = b.Start, b.Count
+		mesh.VertRStart, mesh.VertREnd = b.VertStart, b.VertEnd
+		m.Scene.Children = append(m.Scene.Children, mesh)
+	}
+	return m, nil
+}
+
+func (m *OBJImport) resourceName(suffix string) string {
+	return path.Join(m.Dir, m.Name+suffix)
+}
+
+// resourceFileName turns an OBJ material name into something usable in a file name
+func resourceFileName(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' || r == '_' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' ||
+			r >= 'A' && r <= 'Z' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+func objMaterial(mtl *obj.Material) *MaterialDesc {
+	desc := NewMaterialDesc("shaders/model.shader")
+	if mtl == nil {
+		return desc
+	}
+	if mtl.DiffuseMap != "" {
+		desc.WithSampler("albedoMap", mtl.DiffuseMap)
+	}
+	if mtl.BumpMap != "" {
+		desc.WithFlag("_F02_NormalMapping").WithSampler("normalMap", mtl.BumpMap)
+	}
+	if mtl.Shininess > 0 {
+		mask := max(mtl.Specular.X, mtl.Specular.Y, mtl.Specular.Z)
+		desc.WithUniform("specParams", mask, mtl.Shininess, 0, 0)
+	}
+	return desc
+}
+
+// Create creates the geometry and the materials in memory, and returns the scene graph
+// resource for AddNodes.  The textures and shaders the materials reference are added as
+// unloaded resources, to be loaded like any others.
+func (m *OBJImport) Create(flags int) (SceneGraph, error) {
+	if _, err := CreateGeometry(m.Scene.Geometry, m.Geometry); err != nil {
+		return SceneGraph{}, err
+	}
+	for name, desc := range m.Materials {
+		if _, err := desc.Create(name, flags); err != nil {
+			return SceneGraph{}, err
+		}
+	}
+	var buf bytes.Buffer
+	if err := scene.Encode(&buf, m.Scene); err != nil {
+		return SceneGraph{}, err
+	}
+	r, err := createResource(ResTypes_SceneGraph, m.resourceName(".scene.xml"), flags, buf.Bytes())
+	return SceneGraph{r}, err
+}
+
+// WriteFiles writes the .geo, .material.xml and .scene.xml files to the content directory
+// contentDir, below Dir
+func (m *OBJImport) WriteFiles(contentDir string) error {
+	if err := os.MkdirAll(filepath.Join(contentDir, filepath.FromSlash(m.Dir)), 0755); err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := geo.Encode(&buf, m.Geometry); err != nil {
+		return err
+	}
+	if err := writeContentFile(contentDir, m.Scene.Geometry, &buf); err != nil {
+		return err
+	}
+	for name, desc := range m.Materials {
+		buf.Reset()
+		if err := desc.Encode(&buf); err != nil {
+			return err
+		}
+		if err := writeContentFile(contentDir, name, &buf); err != nil {
+			return err
+		}
+	}
+	buf.Reset()
+	if err := scene.Encode(&buf, m.Scene); err != nil {
+		return err
+	}
+	return writeContentFile(contentDir, m.resourceName(".scene.xml"), &buf)
+}
+
+func writeContentFile(contentDir string, name string, buf *bytes.Buffer) error {
+	return os.WriteFile(filepath.Join(contentDir, filepath.FromSlash(name)), buf.Bytes(), 0644)
+}
@@ -0,0 +1,186 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"bitbucket.org/tshannon/gohorde/horde3d/format/geo"
+	"bitbucket.org/tshannon/gohorde/horde3d/format/scene"
+	"bitbucket.org/tshannon/gohorde/horde3d/math"
+)
+
+var objFS = fstest.MapFS{
+	"import/crate.obj": {Data: []byte(`mtllib crate.mtl
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+usemtl wood planks
+f 1/1 2/2 3/3
+usemtl metal
+f 1/1 3/3 4/4
+`)},
+	"import/crate.mtl": {Data: []byte(`newmtl wood planks
+Ks 0.3 0.2 0.1
+Ns 20
+map_Kd wood.png
+newmtl metal
+bump metal_n.png
+`)},
+}
+
+func TestImportOBJ(t *testing.T) {
+	m, err := ImportOBJ(objFS, "import/crate.obj", "models/crate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Scene.Name != "crate" || m.Scene.Geometry != "models/crate/crate.geo" ||
+		len(m.Scene.Children) != 2 {
+		t.Fatalf("scene %+v", m.Scene)
+	}
+	mesh := m.Scene.Children[1].(*scene.Mesh)
+	if mesh.Material != "models/crate/crate_metal.material.xml" || mesh.BatchStart != 3 ||
+		mesh.BatchCount != 3 {
+		t.Errorf("second mesh %+v", mesh)
+	}
+
+	wood := m.Materials["models/crate/crate_wood_planks.material.xml"]
+	if wood == nil || wood.Sampler("albedoMap").Map != "import/wood.png" ||
+		wood.Uniform("specParams").Value != [4]float32{0.3, 20, 0, 0} {
+		t.Errorf("wood material %+v", wood)
+	}
+	metal := m.Materials["models/crate/crate_metal.material.xml"]
+	if metal == nil || metal.Sampler("normalMap").Map != "import/metal_n.png" ||
+		len(metal.Flags) != 1 || metal.Flags[0] != "_F02_NormalMapping" {
+		t.Errorf("metal material %+v", metal)
+	}
+
+	dir := t.TempDir()
+	if err := m.WriteFiles(dir); err != nil {
+		t.Fatal(err)
+	}
+	content := os.DirFS(dir)
+	root, err := func() (scene.Node, error) {
+		f, err := content.Open("models/crate/crate.scene.xml")
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return scene.Decode(f)
+	}()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := scene.Validate(root, content); err != nil {
+		t.Error(err)
+	}
+	for name := range m.Materials {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := DecodeMaterialDesc(bytes.NewReader(data)); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestImportOBJNameCollision(t *testing.T) {
+	fsys := fstest.MapFS{
+		"import/planks.obj": {Data: []byte(`mtllib planks.mtl
+v 0 0 0
+v 1 0 0
+v 1 1 0
+usemtl wood planks
+f 1 2 3
+usemtl wood_planks
+f 1 3 2
+usemtl wood planks
+f 2 1 3
+`)},
+		"import/planks.mtl": {Data: []byte(`newmtl wood planks
+map_Kd oak.png
+newmtl wood_planks
+map_Kd pine.png
+`)},
+	}
+	m, err := ImportOBJ(fsys, "import/planks.obj", "models/planks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	oak := m.Materials["models/planks/planks_wood_planks.material.xml"]
+	pine := m.Materials["models/planks/planks_wood_planks_2.material.xml"]
+	if len(m.Materials) != 2 || oak == nil || pine == nil ||
+		oak.Sampler("albedoMap").Map != "import/oak.png" ||
+		pine.Sampler("albedoMap").Map != "import/pine.png" {
+		t.Fatalf("materials %v", m.Materials)
+	}
+	for _, child := range m.Scene.Children {
+		mesh := child.(*scene.Mesh)
+		if m.Materials[mesh.Material] == nil {
+			t.Errorf("mesh %s uses missing material %s", mesh.Name, mesh.Material)
+		}
+	}
+}
+
+func TestCreateGeometryInvalid(t *testing.T) {
+	tri := &geo.Geometry{
+		VertexCount: 3,
+		Positions:   []math.Vec3{{}, {X: 1}, {Y: 1}},
+		Indices:     []uint32{0, 1, 2},
+	}
+	for _, g := range []*geo.Geometry{
+		{},
+		{VertexCount: 3, Positions: tri.Positions},
+		{VertexCount: 3, Positions: tri.Positions, Indices: tri.Indices,
+			Joints: []math.Mat4{math.Mat4Identity()}},
+		{VertexCount: 3, Positions: tri.Positions, Indices: tri.Indices,
+			Tangents: make([][3]int16, 3)},
+		{VertexCount: 1000, Positions: tri.Positions, Indices: tri.Indices},
+		{VertexCount: 3, Positions: tri.Positions[:2], Indices: tri.Indices},
+		{VertexCount: 3, Positions: tri.Positions, Indices: tri.Indices,
+			Normals: make([][3]int16, 2)},
+		{VertexCount: 3, Positions: tri.Positions, Indices: tri.Indices,
+			Tangents: make([][3]int16, 3), Bitangents: make([][3]int16, 4)},
+		{VertexCount: 3, Positions: tri.Positions, Indices: tri.Indices,
+			TexCoords0: make([]math.Vec2, 1)},
+		{VertexCount: 3, Positions: tri.Positions, Indices: tri.Indices,
+			TexCoords1: make([]math.Vec2, 6)},
+		{VertexCount: 3, Positions: tri.Positions, Indices: tri.Indices[:2]},
+		{VertexCount: 3, Positions: tri.Positions, Indices: []uint32{0, 1, 3}},
+		{VertexCount: 3, Positions: tri.Positions, Indices: tri.Indices,
+			Normals: [][3]int16{}, Tangents: make([][3]int16, 3), Bitangents: [][3]int16{}},
+	} {
+		if _, err := CreateGeometry("invalid", g); !errors.Is(err, ErrInvalidDesc) {
+			t.Errorf("CreateGeometry(%+v) = %v", g, err)
+		}
+	}
+}
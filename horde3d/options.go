@@ -0,0 +1,158 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"fmt"
+	"time"
+)
+
+var optionNames = map[Option]string{
+	Options_MaxLogLevel:        "MaxLogLevel",
+	Options_MaxNumMessages:     "MaxNumMessages",
+	Options_TrilinearFiltering: "TrilinearFiltering",
+	Options_MaxAnisotropy:      "MaxAnisotropy",
+	Options_TexCompression:     "TexCompression",
+	Options_SRGBLinearization:  "SRGBLinearization",
+	Options_LoadTextures:       "LoadTextures",
+	Options_FastAnimation:      "FastAnimation",
+	Options_ShadowMapSize:      "ShadowMapSize",
+	Options_SampleCount:        "SampleCount",
+	Options_WireframeMode:      "WireframeMode",
+	Options_DebugViewMode:      "DebugViewMode",
+	Options_DumpFailedShaders:  "DumpFailedShaders",
+	Options_GatherTimeStats:    "GatherTimeStats",
+}
+
+func (param Option) String() string {
+	if name, ok := optionNames[param]; ok {
+		return name
+	}
+	return fmt.Sprintf("Option(%d)", int(param))
+}
+
+// Validate checks value against the range documented for the option in H3DOptions
+func (param Option) Validate(value float32) error {
+	var allowed []float32
+	switch param {
+	case Options_MaxLogLevel:
+		if value >= 0 && value <= LogLevel_Debug && value == float32(int(value)) {
+			return nil
+		}
+		return fmt.Errorf("%w: %s must be a whole number between 0 and %d, not %g", ErrInvalidOption,
+			param, LogLevel_Debug, value)
+	case Options_MaxNumMessages:
+		if value >= 1 && value == float32(int(value)) {
+			return nil
+		}
+		return fmt.Errorf("%w: %s must be a positive whole number, not %g", ErrInvalidOption, param, value)
+	case Options_MaxAnisotropy:
+		allowed = []float32{1, 2, 4, 8}
+	case Options_ShadowMapSize:
+		allowed = []float32{128, 256, 512, 1024, 2048}
+	case Options_SampleCount:
+		allowed = []float32{0, 2, 4, 8, 16}
+	case Options_TrilinearFiltering, Options_TexCompression, Options_SRGBLinearization,
+		Options_LoadTextures, Options_FastAnimation, Options_WireframeMode, Options_DebugViewMode,
+		Options_DumpFailedShaders, Options_GatherTimeStats:
+		allowed = []float32{0, 1}
+	default:
+		return fmt.Errorf("%w: unknown option %d", ErrInvalidOption, int(param))
+	}
+
+	for _, v := range allowed {
+		if value == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s must be one of %v, not %g", ErrInvalidOption, param, allowed, value)
+}
+
+var statNames = map[Stat]string{
+	Stats_TriCount:         "TriCount",
+	Stats_BatchCount:       "BatchCount",
+	Stats_LightPassCount:   "LightPassCount",
+	Stats_FrameTime:        "FrameTime",
+	Stats_AnimationTime:    "AnimationTime",
+	Stats_GeoUpdateTime:    "GeoUpdateTime",
+	Stats_ParticleSimTime:  "ParticleSimTime",
+	Stats_FwdLightsGPUTime: "FwdLightsGPUTime",
+	Stats_DefLightsGPUTime: "DefLightsGPUTime",
+	Stats_ShadowsGPUTime:   "ShadowsGPUTime",
+	Stats_ParticleGPUTime:  "ParticleGPUTime",
+	Stats_TextureVMem:      "TextureVMem",
+	Stats_GeometryVMem:     "GeometryVMem",
+}
+
+func (param Stat) String() string {
+	if name, ok := statNames[param]; ok {
+		return name
+	}
+	return fmt.Sprintf("Stat(%d)", int(param))
+}
+
+// FrameStats is a snapshot of the engine statistics
+type FrameStats struct {
+	TriCount       int
+	BatchCount     int
+	LightPassCount int
+
+	FrameTime        time.Duration
+	AnimationTime    time.Duration
+	GeoUpdateTime    time.Duration
+	ParticleSimTime  time.Duration
+	FwdLightsGPUTime time.Duration
+	DefLightsGPUTime time.Duration
+	ShadowsGPUTime   time.Duration
+	ParticleGPUTime  time.Duration
+
+	TextureVMem  int64 // bytes
+	GeometryVMem int64 // bytes
+}
+
+// ReadFrameStats reads all statistics, resetting the counters if reset is true.  Call it once per
+// frame after FinalizeFrame.
+func ReadFrameStats(reset bool) FrameStats {
+	ms := func(param Stat) time.Duration {
+		return time.Duration(float64(param.Value(reset)) * float64(time.Millisecond))
+	}
+	mb := func(param Stat) int64 {
+		return int64(float64(param.Value(reset)) * 1024 * 1024)
+	}
+
+	return FrameStats{
+		TriCount:       int(Stats_TriCount.Value(reset)),
+		BatchCount:     int(Stats_BatchCount.Value(reset)),
+		LightPassCount: int(Stats_LightPassCount.Value(reset)),
+
+		FrameTime:        ms(Stats_FrameTime),
+		AnimationTime:    ms(Stats_AnimationTime),
+		GeoUpdateTime:    ms(Stats_GeoUpdateTime),
+		ParticleSimTime:  ms(Stats_ParticleSimTime),
+		FwdLightsGPUTime: ms(Stats_FwdLightsGPUTime),
+		DefLightsGPUTime: ms(Stats_DefLightsGPUTime),
+		ShadowsGPUTime:   ms(Stats_ShadowsGPUTime),
+		ParticleGPUTime:  ms(Stats_ParticleGPUTime),
+
+		TextureVMem:  mb(Stats_TextureVMem),
+		GeometryVMem: mb(Stats_GeometryVMem),
+	}
+}
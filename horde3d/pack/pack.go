@@ -0,0 +1,87 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+// Package pack implements the H3DP archive format, a simple indexed container for shipping
+// Horde3D content as a single file.
+//
+// An archive starts with a fixed size header, followed by the data of every entry and the table
+// of contents:
+//
+//	magic     [4]byte  "H3DP"
+//	version   uint32
+//	count     uint32   number of entries
+//	reserved  uint32
+//	tocOffset uint64
+//
+// Each table of contents record holds the entry name, the compression method, the modification
+// time, the offset and size of the stored data, the uncompressed size and the SHA-256 of the
+// uncompressed data.  All values are little endian.
+package pack
+
+import (
+	"crypto/sha256"
+	"errors"
+	"time"
+)
+
+const (
+	magic      = "H3DP"
+	version    = 1
+	headerSize = 24
+
+	// maxPrealloc limits the buffer ReadFile allocates up front from an entry size
+	maxPrealloc = 64 << 20
+)
+
+var (
+	ErrFormat   = errors.New("pack: not a valid archive")
+	ErrChecksum = errors.New("pack: checksum error")
+	ErrMethod   = errors.New("pack: unsupported compression method")
+	ErrExists   = errors.New("pack: duplicate entry")
+)
+
+// Method is the compression method of an entry
+type Method uint8
+
+const (
+	Store   Method = iota // uncompressed
+	Deflate               // compressed with compress/flate
+)
+
+func (m Method) String() string {
+	switch m {
+	case Store:
+		return "store"
+	case Deflate:
+		return "deflate"
+	}
+	return "unknown"
+}
+
+// Entry describes a single file in an archive
+type Entry struct {
+	Name     string // slash separated path, valid for fs.FS
+	Method   Method
+	ModTime  time.Time
+	Offset   int64 // offset of the stored data in the archive
+	Size     int64 // size of the stored data
+	OrigSize int64 // size of the uncompressed data
+	Sum      [sha256.Size]byte
+}
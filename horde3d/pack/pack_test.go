@@ -0,0 +1,191 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package pack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+var testFiles = map[string]string{
+	"shaders/model.shader":              "[[FX]]\n",
+	"models/knight/knight.scene.xml":    "<Model name=\"knight\" />\n",
+	"models/knight/knight.material.xml": string(bytes.Repeat([]byte("<Material />\n"), 100)),
+	"readme.txt":                        "",
+}
+
+// build writes the test files to an archive and returns its bytes
+func build(t *testing.T, method Method) []byte {
+	t.Helper()
+	f, err := os.Create(filepath.Join(t.TempDir(), "test.h3dp"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	w, err := NewWriter(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Date(2012, 6, 1, 0, 0, 0, 0, time.UTC)
+	// the first file compresses, so it is deflated with Deflate
+	for _, name := range []string{"models/knight/knight.material.xml", "shaders/model.shader",
+		"models/knight/knight.scene.xml", "readme.txt"} {
+		if err := w.Add(name, modTime, method, []byte(testFiles[name])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Add("readme.txt", modTime, method, nil); !errors.Is(err, ErrExists) {
+		t.Errorf("adding a file twice gives %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(f.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, method := range []Method{Store, Deflate} {
+		data := build(t, method)
+		r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("%v: %v", method, err)
+		}
+		if len(r.Entries()) != len(testFiles) {
+			t.Errorf("%v: %d entries", method, len(r.Entries()))
+		}
+		for name, want := range testFiles {
+			got, err := r.ReadFile(name)
+			if err != nil || string(got) != want {
+				t.Errorf("%v: %s reads %q, %v", method, name, got, err)
+			}
+		}
+		if err := fstest.TestFS(r, "shaders/model.shader", "models/knight/knight.scene.xml",
+			"readme.txt"); err != nil {
+			t.Errorf("%v: %v", method, err)
+		}
+	}
+}
+
+// patchEntry changes the offset, size and original size of the first entry of an archive
+func patchEntry(data []byte, offset, size, origSize uint64) []byte {
+	data = bytes.Clone(data)
+	toc := binary.LittleEndian.Uint64(data[16:])
+	rec := data[toc:]
+	rec = rec[2+int(binary.LittleEndian.Uint16(rec))+1+8:]
+	binary.LittleEndian.PutUint64(rec, offset)
+	binary.LittleEndian.PutUint64(rec[8:], size)
+	binary.LittleEndian.PutUint64(rec[16:], origSize)
+	return data
+}
+
+func firstEntry(t *testing.T, data []byte) Entry {
+	t.Helper()
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r.Entries()[0]
+}
+
+func TestCorruptTOC(t *testing.T) {
+	data := build(t, Deflate)
+	e := firstEntry(t, data)
+	for _, c := range []struct {
+		name                   string
+		offset, size, origSize uint64
+	}{
+		{"offset past toc", 1 << 40, uint64(e.Size), uint64(e.OrigSize)},
+		{"overflowing size", uint64(e.Offset), 1<<63 - 1, uint64(e.OrigSize)},
+		{"negative size", uint64(e.Offset), 1 << 63, uint64(e.OrigSize)},
+		{"offset in header", 0, uint64(e.Size), uint64(e.OrigSize)},
+	} {
+		bad := patchEntry(data, c.offset, c.size, c.origSize)
+		if _, err := NewReader(bytes.NewReader(bad), int64(len(bad))); !errors.Is(err, ErrFormat) {
+			t.Errorf("%s: %v", c.name, err)
+		}
+	}
+
+	stored := build(t, Store)
+	s := firstEntry(t, stored)
+	bad := patchEntry(stored, uint64(s.Offset), uint64(s.Size), uint64(s.Size)+1)
+	if _, err := NewReader(bytes.NewReader(bad), int64(len(bad))); !errors.Is(err, ErrFormat) {
+		t.Errorf("stored entry with a different original size: %v", err)
+	}
+}
+
+func TestWrongSize(t *testing.T) {
+	data := build(t, Deflate)
+	e := firstEntry(t, data)
+	if e.Method != Deflate {
+		t.Fatalf("first entry is %v", e.Method)
+	}
+	for _, origSize := range []uint64{1 << 62, uint64(e.OrigSize) - 1, uint64(e.OrigSize) + 1} {
+		bad := patchEntry(data, uint64(e.Offset), uint64(e.Size), origSize)
+		r, err := NewReader(bytes.NewReader(bad), int64(len(bad)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := r.ReadFile(e.Name); !errors.Is(err, ErrChecksum) {
+			t.Errorf("original size %d: %v", origSize, err)
+		}
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	for _, method := range []Method{Store, Deflate} {
+		data := build(t, method)
+		e := firstEntry(t, data)
+		data[e.Offset+e.Size/2] ^= 0xff
+		r, err := NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err := r.Open(e.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// a flipped bit may also break the deflate stream itself
+		if _, err := io.ReadAll(f); err == nil {
+			t.Errorf("%v: corrupted file read without error", method)
+		} else if method == Store && !errors.Is(err, ErrChecksum) {
+			t.Errorf("%v: %v", method, err)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, data := range [][]byte{nil, []byte("H3DP"), bytes.Repeat([]byte{0}, 64)} {
+		if _, err := NewReader(bytes.NewReader(data), int64(len(data))); err == nil {
+			t.Errorf("%q opened", data)
+		}
+	}
+}
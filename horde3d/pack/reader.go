@@ -0,0 +1,368 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package pack
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Reader gives random access to the entries of an archive.  It implements fs.FS, so it can be
+// passed to the resource loaders or mounted in a VFS directly.
+type Reader struct {
+	r       io.ReaderAt
+	entries []Entry
+	files   map[string]*Entry
+	dirs    map[string][]fs.DirEntry
+}
+
+// NewReader reads the table of contents of the archive of size bytes in r
+func NewReader(r io.ReaderAt, size int64) (*Reader, error) {
+	header := make([]byte, headerSize)
+	if _, err := r.ReadAt(header, 0); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, ErrFormat
+		}
+		return nil, err
+	}
+	if string(header[:4]) != magic || binary.LittleEndian.Uint32(header[4:]) != version {
+		return nil, ErrFormat
+	}
+	count := int(binary.LittleEndian.Uint32(header[8:]))
+	tocOffset := int64(binary.LittleEndian.Uint64(header[16:]))
+	if tocOffset < headerSize || tocOffset > size {
+		return nil, ErrFormat
+	}
+
+	toc := make([]byte, size-tocOffset)
+	if _, err := r.ReadAt(toc, tocOffset); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	rd := &Reader{
+		r:     r,
+		files: make(map[string]*Entry),
+		dirs:  map[string][]fs.DirEntry{".": nil},
+	}
+	for i := 0; i < count; i++ {
+		var e Entry
+		var ok bool
+		if e, toc, ok = readEntry(toc); !ok {
+			return nil, ErrFormat
+		}
+		if !fs.ValidPath(e.Name) || e.Name == "." || e.Offset < headerSize || e.Size < 0 ||
+			e.OrigSize < 0 || e.Size > tocOffset-e.Offset ||
+			e.Method == Store && e.Size != e.OrigSize {
+			return nil, ErrFormat
+		}
+		rd.entries = append(rd.entries, e)
+	}
+	for i := range rd.entries {
+		if err := rd.index(&rd.entries[i]); err != nil {
+			return nil, err
+		}
+	}
+	for _, list := range rd.dirs {
+		slices.SortFunc(list, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	}
+	return rd, nil
+}
+
+func readEntry(b []byte) (Entry, []byte, bool) {
+	var e Entry
+	if len(b) < 2 {
+		return e, b, false
+	}
+	n := int(binary.LittleEndian.Uint16(b))
+	b = b[2:]
+	if len(b) < n+1+8*4+sha256.Size {
+		return e, b, false
+	}
+	e.Name = string(b[:n])
+	b = b[n:]
+	e.Method = Method(b[0])
+	b = b[1:]
+	if modTime := int64(binary.LittleEndian.Uint64(b)); modTime != 0 {
+		e.ModTime = time.Unix(0, modTime)
+	}
+	e.Offset = int64(binary.LittleEndian.Uint64(b[8:]))
+	e.Size = int64(binary.LittleEndian.Uint64(b[16:]))
+	e.OrigSize = int64(binary.LittleEndian.Uint64(b[24:]))
+	b = b[32:]
+	copy(e.Sum[:], b)
+	return e, b[sha256.Size:], true
+}
+
+// index adds the entry and its parent directories to the lookup tables
+func (r *Reader) index(e *Entry) error {
+	if _, ok := r.files[e.Name]; ok {
+		return ErrFormat
+	}
+	if _, ok := r.dirs[e.Name]; ok {
+		return ErrFormat
+	}
+	r.files[e.Name] = e
+
+	child := fs.FileInfoToDirEntry(fileInfo{name: path.Base(e.Name), size: e.OrigSize, modTime: e.ModTime})
+	for dir := path.Dir(e.Name); ; dir = path.Dir(dir) {
+		if _, ok := r.files[dir]; ok {
+			return ErrFormat
+		}
+		list, seen := r.dirs[dir]
+		r.dirs[dir] = append(list, child)
+		if seen || dir == "." {
+			return nil
+		}
+		child = fs.FileInfoToDirEntry(fileInfo{name: path.Base(dir), dir: true})
+	}
+}
+
+// ReadCloser is a Reader for an archive file opened with OpenReader
+type ReadCloser struct {
+	Reader
+	f *os.File
+}
+
+// OpenReader opens the named archive file
+func OpenReader(name string) (*ReadCloser, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r, err := NewReader(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &ReadCloser{Reader: *r, f: f}, nil
+}
+
+// Close closes the archive file
+func (rc *ReadCloser) Close() error {
+	return rc.f.Close()
+}
+
+// Entries returns the entries in the order they were added to the archive
+func (r *Reader) Entries() []Entry {
+	return r.entries
+}
+
+// Open opens the named file or directory.  Files read to the end are checked against their
+// checksum, unless they were seeked in.
+func (r *Reader) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if list, ok := r.dirs[name]; ok {
+		return &dir{info: fileInfo{name: path.Base(name), dir: true}, entries: list}, nil
+	}
+	e, ok := r.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	f := &file{
+		entry:   e,
+		section: io.NewSectionReader(r.r, e.Offset, e.Size),
+		hash:    sha256.New(),
+		verify:  true,
+	}
+	switch e.Method {
+	case Store:
+		f.reader = f.section
+		return &storedFile{f}, nil
+	case Deflate:
+		f.decomp = flate.NewReader(f.section)
+		f.reader = f.decomp
+		return f, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: ErrMethod}
+}
+
+// ReadFile reads and verifies the named file
+func (r *Reader) ReadFile(name string) ([]byte, error) {
+	f, err := r.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	e, ok := r.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "read", Path: name, Err: errors.New("is a directory")}
+	}
+	// the size comes from the archive, so it is only trusted up to a limit
+	var buf bytes.Buffer
+	buf.Grow(int(min(e.OrigSize, maxPrealloc)))
+	if _, err := buf.ReadFrom(f); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ReadDir returns the entries of the named directory
+func (r *Reader) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	list, ok := r.dirs[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return slices.Clone(list), nil
+}
+
+// Stat returns the file info of the named file or directory
+func (r *Reader) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	if _, ok := r.dirs[name]; ok {
+		return fileInfo{name: path.Base(name), dir: true}, nil
+	}
+	if e, ok := r.files[name]; ok {
+		return e.info(), nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+}
+
+func (e *Entry) info() fileInfo {
+	return fileInfo{name: path.Base(e.Name), size: e.OrigSize, modTime: e.ModTime}
+}
+
+type fileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (fi fileInfo) Name() string       { return fi.name }
+func (fi fileInfo) Size() int64        { return fi.size }
+func (fi fileInfo) ModTime() time.Time { return fi.modTime }
+func (fi fileInfo) IsDir() bool        { return fi.dir }
+func (fi fileInfo) Sys() any           { return nil }
+
+func (fi fileInfo) Mode() fs.FileMode {
+	if fi.dir {
+		return fs.ModeDir | 0555
+	}
+	return 0444
+}
+
+type file struct {
+	entry   *Entry
+	section *io.SectionReader
+	decomp  io.ReadCloser
+	reader  io.Reader
+	hash    hash.Hash
+	read    int64
+	verify  bool
+}
+
+func (f *file) Stat() (fs.FileInfo, error) {
+	return f.entry.info(), nil
+}
+
+func (f *file) Read(p []byte) (int, error) {
+	n, err := f.reader.Read(p)
+	if f.verify {
+		f.hash.Write(p[:n])
+		f.read += int64(n)
+		switch {
+		case f.read > f.entry.OrigSize:
+			err = &fs.PathError{Op: "read", Path: f.entry.Name, Err: ErrChecksum}
+		case err == io.EOF && (f.read != f.entry.OrigSize ||
+			!bytes.Equal(f.hash.Sum(nil), f.entry.Sum[:])):
+			err = &fs.PathError{Op: "read", Path: f.entry.Name, Err: ErrChecksum}
+		}
+	}
+	return n, err
+}
+
+// storedFile is an uncompressed entry, which supports random access
+type storedFile struct {
+	*file
+}
+
+func (f *storedFile) ReadAt(p []byte, off int64) (int, error) {
+	return f.section.ReadAt(p, off)
+}
+
+func (f *storedFile) Seek(offset int64, whence int) (int64, error) {
+	f.verify = false
+	return f.section.Seek(offset, whence)
+}
+
+func (f *file) Close() error {
+	if f.decomp != nil {
+		return f.decomp.Close()
+	}
+	return nil
+}
+
+type dir struct {
+	info    fileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) {
+	return d.info, nil
+}
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: errors.New("is a directory")}
+}
+
+func (d *dir) Close() error {
+	return nil
+}
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return slices.Clone(rest), nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rest))
+	d.offset += n
+	return slices.Clone(rest[:n]), nil
+}
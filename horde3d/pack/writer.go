@@ -0,0 +1,182 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package pack
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"time"
+)
+
+// Writer builds an archive.  The header is written again by Close, once the position of the
+// table of contents is known, so the destination has to be seekable.
+type Writer struct {
+	w       io.WriteSeeker
+	start   int64 // position of the archive in w
+	offset  int64 // offset of the next entry, relative to start
+	entries []Entry
+	names   map[string]bool
+	closed  bool
+}
+
+// NewWriter starts a new archive at the current position of w
+func NewWriter(w io.WriteSeeker) (*Writer, error) {
+	start, err := w.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(make([]byte, headerSize)); err != nil {
+		return nil, err
+	}
+	return &Writer{w: w, start: start, offset: headerSize, names: make(map[string]bool)}, nil
+}
+
+// Add stores data under name.  With Deflate, the data is stored uncompressed anyway if
+// compressing doesn't make it smaller.
+func (w *Writer) Add(name string, modTime time.Time, method Method, data []byte) error {
+	if w.closed {
+		return errors.New("pack: writer is closed")
+	}
+	if !fs.ValidPath(name) || name == "." || len(name) > math.MaxUint16 {
+		return fmt.Errorf("pack: invalid entry name %q", name)
+	}
+	if w.names[name] {
+		return fmt.Errorf("%w: %s", ErrExists, name)
+	}
+
+	stored := data
+	switch method {
+	case Store:
+	case Deflate:
+		var buf bytes.Buffer
+		fw, err := flate.NewWriter(&buf, flate.BestCompression)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write(data); err != nil {
+			return err
+		}
+		if err := fw.Close(); err != nil {
+			return err
+		}
+		if buf.Len() < len(data) {
+			stored = buf.Bytes()
+		} else {
+			method = Store
+		}
+	default:
+		return ErrMethod
+	}
+
+	if _, err := w.w.Write(stored); err != nil {
+		return err
+	}
+	w.names[name] = true
+	w.entries = append(w.entries, Entry{
+		Name:     name,
+		Method:   method,
+		ModTime:  modTime,
+		Offset:   w.offset,
+		Size:     int64(len(stored)),
+		OrigSize: int64(len(data)),
+		Sum:      sha256.Sum256(data),
+	})
+	w.offset += int64(len(stored))
+	return nil
+}
+
+// AddFS adds every regular file of fsys, using its path within fsys as the entry name
+func (w *Writer) AddFS(fsys fs.FS, method Method) error {
+	return fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return err
+		}
+		return w.Add(name, info.ModTime(), method, data)
+	})
+}
+
+// Entries returns the entries added so far
+func (w *Writer) Entries() []Entry {
+	return w.entries
+}
+
+// Close writes the table of contents and the header.  It does not close the underlying writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+
+	var toc []byte
+	for _, e := range w.entries {
+		toc = appendEntry(toc, e)
+	}
+	if _, err := w.w.Write(toc); err != nil {
+		return err
+	}
+	end := w.start + w.offset + int64(len(toc))
+
+	header := make([]byte, 0, headerSize)
+	header = append(header, magic...)
+	header = binary.LittleEndian.AppendUint32(header, version)
+	header = binary.LittleEndian.AppendUint32(header, uint32(len(w.entries)))
+	header = binary.LittleEndian.AppendUint32(header, 0)
+	header = binary.LittleEndian.AppendUint64(header, uint64(w.offset))
+
+	if _, err := w.w.Seek(w.start, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := w.w.Write(header); err != nil {
+		return err
+	}
+	_, err := w.w.Seek(end, io.SeekStart)
+	return err
+}
+
+func appendEntry(b []byte, e Entry) []byte {
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(e.Name)))
+	b = append(b, e.Name...)
+	b = append(b, byte(e.Method))
+	var modTime int64
+	if !e.ModTime.IsZero() {
+		modTime = e.ModTime.UnixNano()
+	}
+	b = binary.LittleEndian.AppendUint64(b, uint64(modTime))
+	b = binary.LittleEndian.AppendUint64(b, uint64(e.Offset))
+	b = binary.LittleEndian.AppendUint64(b, uint64(e.Size))
+	b = binary.LittleEndian.AppendUint64(b, uint64(e.OrigSize))
+	return append(b, e.Sum[:]...)
+}
@@ -0,0 +1,194 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Channel names of a particle effect, in the order the engine defines them.  The engine matches
+// them ignoring case, and so do the descriptions.
+var particleChannels = []string{"moveVel", "rotVel", "drag", "size", "colR", "colG", "colB", "colA"}
+
+// ParticleEffectDesc describes a particle effect as in a .particle.xml file.  It can be changed
+// and created under a new name to make variations of an effect at runtime.
+type ParticleEffectDesc struct {
+	LifeMin  float32
+	LifeMax  float32
+	Channels []ParticleChannelDesc
+}
+
+// ParticleChannelDesc sets a channel of a particle effect.  A particle starts with a random value
+// between StartMin and StartMax, which is multiplied by EndRate over the life of the particle.
+type ParticleChannelDesc struct {
+	Name string
+	ParticleChannel
+}
+
+type particleEffectXML struct {
+	XMLName  xml.Name             `xml:"ParticleEffect"`
+	LifeMin  float32              `xml:"lifeMin,attr"`
+	LifeMax  float32              `xml:"lifeMax,attr"`
+	Channels []particleChannelXML `xml:"ChannelOverLife"`
+}
+
+type particleChannelXML struct {
+	Channel  string   `xml:"channel,attr"`
+	StartMin float32  `xml:"startMin,attr"`
+	StartMax float32  `xml:"startMax,attr"`
+	EndRate  *float32 `xml:"endRate,attr"`
+}
+
+// NewParticleEffectDesc starts the description of a particle effect whose particles live between
+// lifeMin and lifeMax seconds
+func NewParticleEffectDesc(lifeMin float32, lifeMax float32) *ParticleEffectDesc {
+	return &ParticleEffectDesc{LifeMin: lifeMin, LifeMax: lifeMax}
+}
+
+// WithChannel sets a channel, replacing its earlier settings
+func (p *ParticleEffectDesc) WithChannel(name string, startMin float32, startMax float32,
+	endRate float32) *ParticleEffectDesc {
+	channel := ParticleChannel{StartMin: startMin, StartMax: startMax, EndRate: endRate}
+	if c := p.Channel(name); c != nil {
+		c.ParticleChannel = channel
+		return p
+	}
+	p.Channels = append(p.Channels, ParticleChannelDesc{Name: name, ParticleChannel: channel})
+	return p
+}
+
+// WithColor sets the start color of the particles, keeping the end rates of the color channels
+func (p *ParticleEffectDesc) WithColor(r float32, g float32, b float32) *ParticleEffectDesc {
+	for i, name := range []string{"colR", "colG", "colB"} {
+		v := [3]float32{r, g, b}[i]
+		endRate := float32(1)
+		if c := p.Channel(name); c != nil {
+			endRate = c.EndRate
+		}
+		p.WithChannel(name, v, v, endRate)
+	}
+	return p
+}
+
+// Channel returns the channel with the given name, ignoring case, or nil
+func (p *ParticleEffectDesc) Channel(name string) *ParticleChannelDesc {
+	i := slices.IndexFunc(p.Channels, func(c ParticleChannelDesc) bool {
+		return strings.EqualFold(c.Name, name)
+	})
+	if i < 0 {
+		return nil
+	}
+	return &p.Channels[i]
+}
+
+// Clone returns a copy of the description that can be changed independently
+func (p *ParticleEffectDesc) Clone() *ParticleEffectDesc {
+	c := *p
+	c.Channels = slices.Clone(p.Channels)
+	return &c
+}
+
+// DecodeParticleEffectDesc reads a .particle.xml file
+func DecodeParticleEffectDesc(r io.Reader) (*ParticleEffectDesc, error) {
+	var x particleEffectXML
+	if err := xml.NewDecoder(r).Decode(&x); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDesc, err)
+	}
+
+	p := &ParticleEffectDesc{LifeMin: x.LifeMin, LifeMax: x.LifeMax}
+	for _, c := range x.Channels {
+		// the engine defaults to a constant value over life
+		endRate := float32(1)
+		if c.EndRate != nil {
+			endRate = *c.EndRate
+		}
+		channel := ParticleChannel{StartMin: c.StartMin, StartMax: c.StartMax, EndRate: endRate}
+		p.Channels = append(p.Channels, ParticleChannelDesc{Name: c.Channel, ParticleChannel: channel})
+	}
+	return p, nil
+}
+
+// Encode writes the particle effect as a .particle.xml file
+func (p *ParticleEffectDesc) Encode(w io.Writer) error {
+	x := particleEffectXML{LifeMin: p.LifeMin, LifeMax: p.LifeMax}
+	for _, c := range p.Channels {
+		x.Channels = append(x.Channels, particleChannelXML{Channel: c.Name, StartMin: c.StartMin,
+			StartMax: c.StartMax, EndRate: &c.EndRate})
+	}
+	return encodeXML(w, x)
+}
+
+// Validate checks the channel names and that the ranges are ordered and not negative for the
+// life time, sizes and colors
+func (p *ParticleEffectDesc) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: particle effect: %s", ErrInvalidDesc,
+			fmt.Sprintf(format, args...)))
+	}
+
+	if p.LifeMin <= 0 || p.LifeMax < p.LifeMin {
+		fail("life range %g to %g is not positive and ordered", p.LifeMin, p.LifeMax)
+	}
+	for i, c := range p.Channels {
+		channel := slices.IndexFunc(particleChannels, func(name string) bool {
+			return strings.EqualFold(name, c.Name)
+		})
+		switch {
+		case channel < 0:
+			fail("unknown channel %q", c.Name)
+			continue
+		case p.Channel(c.Name) != &p.Channels[i]:
+			fail("channel %s is set twice", c.Name)
+			continue
+		}
+		if c.StartMax < c.StartMin {
+			fail("channel %s: startMax %g is smaller than startMin %g", c.Name, c.StartMax, c.StartMin)
+		}
+		if c.EndRate < 0 {
+			fail("channel %s: endRate %g is negative", c.Name, c.EndRate)
+		}
+		switch particleChannels[channel] {
+		case "size", "drag", "colR", "colG", "colB", "colA":
+			if c.StartMin < 0 {
+				fail("channel %s: startMin %g is negative", c.Name, c.StartMin)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Create adds a particle effect resource with the given name and loads the description into it,
+// for use with AddEmitterNode
+func (p *ParticleEffectDesc) Create(name string, flags int) (ParticleEffect, error) {
+	var buf bytes.Buffer
+	if err := p.Encode(&buf); err != nil {
+		return ParticleEffect{}, err
+	}
+	r, err := createResource(ResTypes_ParticleEffect, name, flags, buf.Bytes())
+	return ParticleEffect{r}, err
+}
@@ -0,0 +1,122 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExampleParticleEffects(t *testing.T) {
+	for _, name := range []string{"particle1", "particle2"} {
+		data, err := os.ReadFile(contentDir + "/particles/particleSys1/" + name + ".particle.xml")
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := DecodeParticleEffectDesc(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if len(p.Channels) != 7 || p.LifeMin <= 0 || p.LifeMax <= p.LifeMin {
+			t.Errorf("%s: %+v", name, p)
+		}
+		if err := p.Validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+
+		var buf bytes.Buffer
+		if err := p.Encode(&buf); err != nil {
+			t.Fatal(err)
+		}
+		again, err := DecodeParticleEffectDesc(&buf)
+		if err != nil {
+			t.Fatalf("%s: decoding the encoded effect: %v", name, err)
+		}
+		if !reflect.DeepEqual(again, p) {
+			t.Errorf("%s: round trip gave %+v, want %+v", name, again, p)
+		}
+	}
+
+	p, err := DecodeParticleEffectDesc(strings.NewReader(`<ParticleEffect lifeMin="1" lifeMax="2">
+	<ChannelOverLife channel="size" startMin="0.5" startMax="1" />
+</ParticleEffect>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Channel("size").EndRate != 1 {
+		t.Errorf("missing endRate decoded as %g instead of 1", p.Channel("size").EndRate)
+	}
+}
+
+func TestParticleValidate(t *testing.T) {
+	p := NewParticleEffectDesc(2, 1).
+		WithChannel("size", -1, 1, 1).
+		WithChannel("colA", 1, 0.5, -2).
+		WithChannel("moveVel", -3, 3, 0).
+		WithChannel("spin", 0, 0, 1)
+	p.Channels = append(p.Channels, ParticleChannelDesc{Name: "movevel"})
+	err := p.Validate()
+	if !errors.Is(err, ErrInvalidDesc) {
+		t.Fatalf("%v is not ErrInvalidDesc", err)
+	}
+	for _, want := range []string{
+		"life range 2 to 1", "channel size: startMin -1 is negative",
+		"channel colA: startMax 0.5 is smaller than startMin 1", "channel colA: endRate -2",
+		`unknown channel "spin"`, "channel movevel is set twice",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("no %q in:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "moveVel:") {
+		t.Errorf("a negative velocity is allowed:\n%v", err)
+	}
+
+	if err := NewParticleEffectDesc(1, 1).WithChannel("ColR", 0, 1, 1).Validate(); err != nil {
+		t.Errorf("channel names should match ignoring case: %v", err)
+	}
+}
+
+func TestWithColor(t *testing.T) {
+	p := NewParticleEffectDesc(1, 2).WithChannel("colR", 0, 1, 0.5).WithChannel("colb", 0, 1, 0)
+	p.WithColor(0.2, 0.4, 0.6)
+	for _, want := range []ParticleChannelDesc{
+		{"colR", ParticleChannel{StartMin: 0.2, StartMax: 0.2, EndRate: 0.5}},
+		{"colb", ParticleChannel{StartMin: 0.6, StartMax: 0.6, EndRate: 0}},
+		{"colG", ParticleChannel{StartMin: 0.4, StartMax: 0.4, EndRate: 1}},
+	} {
+		if c := p.Channel(want.Name); c == nil || *c != want {
+			t.Errorf("channel %s = %+v, want %+v", want.Name, c, want)
+		}
+	}
+	if len(p.Channels) != 3 {
+		t.Errorf("%d channels instead of 3", len(p.Channels))
+	}
+
+	clone := p.Clone().WithColor(1, 1, 1)
+	if p.Channel("colR").StartMin != 0.2 || clone.Channel("colR").StartMin != 1 {
+		t.Error("changing a clone changed the original")
+	}
+}
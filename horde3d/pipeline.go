@@ -0,0 +1,555 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+
+	"bitbucket.org/tshannon/gohorde/horde3d/format/shader"
+)
+
+// PipelineDesc describes a render pipeline as in a .pipeline.xml file
+type PipelineDesc struct {
+	RenderTargets []RenderTargetDesc
+	Stages        []*StageDesc
+}
+
+// RenderTargetDesc describes a render target of a pipeline.  Without an explicit size, the
+// target is sized relative to the viewport by Scale.
+type RenderTargetDesc struct {
+	ID         string  `xml:"id,attr"`
+	DepthBuf   bool    `xml:"depthBuf,attr"`
+	NumColBufs int     `xml:"numColBufs,attr"`
+	Format     string  `xml:"format,attr,omitempty"` // RGBA8, RGBA16F or RGBA32F
+	Scale      float32 `xml:"scale,attr,omitempty"`
+	Width      int     `xml:"width,attr,omitempty"`
+	Height     int     `xml:"height,attr,omitempty"`
+	MaxSamples int     `xml:"maxSamples,attr,omitempty"`
+}
+
+// StageDesc is a named list of commands.  Samplers and uniforms the materials of the stage
+// don't set are taken from the linked material.
+type StageDesc struct {
+	ID       string
+	Link     string
+	Disabled bool
+	Commands []PipelineCommand
+}
+
+// PipelineCommand is one of the *Cmd types
+type PipelineCommand interface {
+	element() string
+}
+
+// SwitchTargetCmd renders to a render target, or to the main buffer if Target is empty
+type SwitchTargetCmd struct {
+	Target string `xml:"target,attr"`
+}
+
+// BindBufferCmd binds a buffer of a render target to a sampler, buffer 32 is the depth buffer
+type BindBufferCmd struct {
+	Sampler  string `xml:"sampler,attr"`
+	SourceRT string `xml:"sourceRT,attr"`
+	BufIndex int    `xml:"bufIndex,attr"`
+}
+
+type UnbindBuffersCmd struct{}
+
+type ClearTargetCmd struct {
+	DepthBuf bool    `xml:"depthBuf,attr,omitempty"`
+	ColBuf0  bool    `xml:"colBuf0,attr,omitempty"`
+	ColBuf1  bool    `xml:"colBuf1,attr,omitempty"`
+	ColBuf2  bool    `xml:"colBuf2,attr,omitempty"`
+	ColBuf3  bool    `xml:"colBuf3,attr,omitempty"`
+	ColR     float32 `xml:"col_R,attr,omitempty"`
+	ColG     float32 `xml:"col_G,attr,omitempty"`
+	ColB     float32 `xml:"col_B,attr,omitempty"`
+	ColA     float32 `xml:"col_A,attr,omitempty"`
+}
+
+// DrawGeometryCmd draws the geometry whose materials match Class with the shader context
+// Context.  A class starting with ~ matches all other materials.
+type DrawGeometryCmd struct {
+	Context string `xml:"context,attr"`
+	Class   string `xml:"class,attr,omitempty"`
+	Order   string `xml:"order,attr,omitempty"` // NONE, FRONT_TO_BACK, BACK_TO_FRONT or STATECHANGES
+}
+
+type DrawOverlaysCmd struct {
+	Context string `xml:"context,attr"`
+}
+
+type DrawQuadCmd struct {
+	Material string `xml:"material,attr"`
+	Context  string `xml:"context,attr"`
+}
+
+type DoForwardLightLoopCmd struct {
+	Context   string `xml:"context,attr,omitempty"`
+	Class     string `xml:"class,attr,omitempty"`
+	NoShadows bool   `xml:"noShadows,attr,omitempty"`
+	Order     string `xml:"order,attr,omitempty"`
+}
+
+type DoDeferredLightLoopCmd struct {
+	Context   string `xml:"context,attr,omitempty"`
+	NoShadows bool   `xml:"noShadows,attr,omitempty"`
+}
+
+type SetUniformCmd struct {
+	Material string  `xml:"material,attr"`
+	Uniform  string  `xml:"uniform,attr"`
+	A        float32 `xml:"a,attr"`
+	B        float32 `xml:"b,attr,omitempty"`
+	C        float32 `xml:"c,attr,omitempty"`
+	D        float32 `xml:"d,attr,omitempty"`
+}
+
+func (*SwitchTargetCmd) element() string        { return "SwitchTarget" }
+func (*BindBufferCmd) element() string          { return "BindBuffer" }
+func (*UnbindBuffersCmd) element() string       { return "UnbindBuffers" }
+func (*ClearTargetCmd) element() string         { return "ClearTarget" }
+func (*DrawGeometryCmd) element() string        { return "DrawGeometry" }
+func (*DrawOverlaysCmd) element() string        { return "DrawOverlays" }
+func (*DrawQuadCmd) element() string            { return "DrawQuad" }
+func (*DoForwardLightLoopCmd) element() string  { return "DoForwardLightLoop" }
+func (*DoDeferredLightLoopCmd) element() string { return "DoDeferredLightLoop" }
+func (*SetUniformCmd) element() string          { return "SetUniform" }
+
+func newPipelineCommand(element string) PipelineCommand {
+	switch element {
+	case "SwitchTarget":
+		return &SwitchTargetCmd{}
+	case "BindBuffer":
+		return &BindBufferCmd{}
+	case "UnbindBuffers":
+		return &UnbindBuffersCmd{}
+	case "ClearTarget":
+		return &ClearTargetCmd{}
+	case "DrawGeometry":
+		return &DrawGeometryCmd{}
+	case "DrawOverlays":
+		return &DrawOverlaysCmd{}
+	case "DrawQuad":
+		return &DrawQuadCmd{}
+	case "DoForwardLightLoop":
+		return &DoForwardLightLoopCmd{}
+	case "DoDeferredLightLoop":
+		return &DoDeferredLightLoopCmd{}
+	case "SetUniform":
+		return &SetUniformCmd{}
+	}
+	return nil
+}
+
+// NewPipelineDesc starts the description of a pipeline
+func NewPipelineDesc() *PipelineDesc {
+	return &PipelineDesc{}
+}
+
+// AddRenderTarget adds a render target sized relative to the viewport
+func (p *PipelineDesc) AddRenderTarget(id string, format string, numColBufs int, depthBuf bool,
+	scale float32) *PipelineDesc {
+	p.RenderTargets = append(p.RenderTargets, RenderTargetDesc{ID: id, Format: format,
+		NumColBufs: numColBufs, DepthBuf: depthBuf, Scale: scale})
+	return p
+}
+
+// AddStage adds a stage and returns it for adding commands
+func (p *PipelineDesc) AddStage(id string) *StageDesc {
+	s := &StageDesc{ID: id}
+	p.Stages = append(p.Stages, s)
+	return s
+}
+
+// RenderTarget returns the render target with the given id, or nil
+func (p *PipelineDesc) RenderTarget(id string) *RenderTargetDesc {
+	for i := range p.RenderTargets {
+		if p.RenderTargets[i].ID == id {
+			return &p.RenderTargets[i]
+		}
+	}
+	return nil
+}
+
+// Stage returns the stage with the given id, or nil
+func (p *PipelineDesc) Stage(id string) *StageDesc {
+	for _, s := range p.Stages {
+		if s.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+func (s *StageDesc) WithLink(material string) *StageDesc {
+	s.Link = material
+	return s
+}
+
+func (s *StageDesc) add(cmd PipelineCommand) *StageDesc {
+	s.Commands = append(s.Commands, cmd)
+	return s
+}
+
+func (s *StageDesc) SwitchTarget(target string) *StageDesc {
+	return s.add(&SwitchTargetCmd{Target: target})
+}
+
+func (s *StageDesc) BindBuffer(sampler string, sourceRT string, bufIndex int) *StageDesc {
+	return s.add(&BindBufferCmd{Sampler: sampler, SourceRT: sourceRT, BufIndex: bufIndex})
+}
+
+func (s *StageDesc) UnbindBuffers() *StageDesc {
+	return s.add(&UnbindBuffersCmd{})
+}
+
+// ClearTarget clears the depth buffer and the first color buffer of the current target
+func (s *StageDesc) ClearTarget(r float32, g float32, b float32, a float32) *StageDesc {
+	return s.add(&ClearTargetCmd{DepthBuf: true, ColBuf0: true, ColR: r, ColG: g, ColB: b, ColA: a})
+}
+
+func (s *StageDesc) DrawGeometry(context string, class string) *StageDesc {
+	return s.add(&DrawGeometryCmd{Context: context, Class: class})
+}
+
+func (s *StageDesc) DrawOverlays(context string) *StageDesc {
+	return s.add(&DrawOverlaysCmd{Context: context})
+}
+
+func (s *StageDesc) DrawQuad(material string, context string) *StageDesc {
+	return s.add(&DrawQuadCmd{Material: material, Context: context})
+}
+
+func (s *StageDesc) DoForwardLightLoop(class string) *StageDesc {
+	return s.add(&DoForwardLightLoopCmd{Class: class})
+}
+
+func (s *StageDesc) DoDeferredLightLoop() *StageDesc {
+	return s.add(&DoDeferredLightLoopCmd{})
+}
+
+func (s *StageDesc) SetUniform(material string, uniform string, a float32, b float32, c float32,
+	d float32) *StageDesc {
+	return s.add(&SetUniformCmd{Material: material, Uniform: uniform, A: a, B: b, C: c, D: d})
+}
+
+type pipelineXML struct {
+	XMLName  xml.Name     `xml:"Pipeline"`
+	Setup    *setupXML    `xml:"Setup"`
+	Commands []*StageDesc `xml:"CommandQueue>Stage"`
+}
+
+type setupXML struct {
+	RenderTargets []RenderTargetDesc `xml:"RenderTarget"`
+}
+
+// DecodePipelineDesc reads a .pipeline.xml file
+func DecodePipelineDesc(r io.Reader) (*PipelineDesc, error) {
+	var x pipelineXML
+	if err := xml.NewDecoder(r).Decode(&x); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDesc, err)
+	}
+	p := &PipelineDesc{Stages: x.Commands}
+	if x.Setup != nil {
+		p.RenderTargets = x.Setup.RenderTargets
+	}
+	return p, nil
+}
+
+// Encode writes the pipeline as a .pipeline.xml file
+func (p *PipelineDesc) Encode(w io.Writer) error {
+	x := pipelineXML{Commands: p.Stages}
+	if len(p.RenderTargets) > 0 {
+		x.Setup = &setupXML{RenderTargets: p.RenderTargets}
+	}
+	return encodeXML(w, x)
+}
+
+func (s *StageDesc) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for _, a := range start.Attr {
+		switch a.Name.Local {
+		case "id":
+			s.ID = a.Value
+		case "link":
+			s.Link = a.Value
+		case "enabled":
+			s.Disabled = strings.EqualFold(a.Value, "false") || a.Value == "0"
+		}
+	}
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			cmd := newPipelineCommand(t.Name.Local)
+			if cmd == nil {
+				return fmt.Errorf("stage %q: unknown command %s", s.ID, t.Name.Local)
+			}
+			if err := d.DecodeElement(cmd, &t); err != nil {
+				return err
+			}
+			s.Commands = append(s.Commands, cmd)
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func (s *StageDesc) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start.Attr = []xml.Attr{{Name: xml.Name{Local: "id"}, Value: s.ID}}
+	if s.Link != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "link"}, Value: s.Link})
+	}
+	if s.Disabled {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "enabled"}, Value: "false"})
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	for _, cmd := range s.Commands {
+		el := xml.StartElement{Name: xml.Name{Local: cmd.element()}}
+		if err := e.EncodeElement(cmd, el); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// Validate checks the render targets and the references of the commands to them.  If fsys is
+// not nil, the materials the pipeline references are read from it to check that they exist and
+// set the uniforms changed by SetUniform commands, and the shaders of DrawQuad materials are
+// read to check that they declare the context.
+func (p *PipelineDesc) Validate(fsys fs.FS) error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("%w: pipeline: %s", ErrInvalidDesc, fmt.Sprintf(format, args...)))
+	}
+
+	for i, rt := range p.RenderTargets {
+		if rt.ID == "" {
+			fail("render target %d has no id", i)
+		} else if p.RenderTarget(rt.ID) != &p.RenderTargets[i] {
+			fail("render target %q is defined twice", rt.ID)
+		}
+		if rt.NumColBufs < 0 || rt.NumColBufs > 4 {
+			fail("render target %q has %d color buffers, at most 4 are supported", rt.ID, rt.NumColBufs)
+		}
+		switch rt.Format {
+		case "", "RGBA8", "RGBA16F", "RGBA32F":
+		default:
+			fail("render target %q has unknown format %q", rt.ID, rt.Format)
+		}
+		if rt.Scale < 0 || rt.Width < 0 || rt.Height < 0 || rt.MaxSamples < 0 {
+			fail("render target %q has a negative size or sample count", rt.ID)
+		}
+	}
+
+	materials := &materialChecker{
+		fsys:    fsys,
+		descs:   make(map[string]*MaterialDesc),
+		shaders: make(map[string]ShaderInfo),
+	}
+	for i, s := range p.Stages {
+		if s.ID == "" {
+			fail("stage %d has no id", i)
+		} else if p.Stage(s.ID) != s {
+			fail("stage %q is defined twice", s.ID)
+		}
+		where := fmt.Sprintf("stage %q", s.ID)
+		if s.Link != "" {
+			if err := materials.check(s.Link, ""); err != nil {
+				fail("%s: link: %v", where, err)
+			}
+		}
+		for _, cmd := range s.Commands {
+			if msg := p.checkCommand(cmd, materials); msg != "" {
+				fail("%s: %s: %s", where, cmd.element(), msg)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *PipelineDesc) checkCommand(cmd PipelineCommand, materials *materialChecker) string {
+	switch c := cmd.(type) {
+	case *SwitchTargetCmd:
+		if c.Target != "" && p.RenderTarget(c.Target) == nil {
+			return fmt.Sprintf("unknown render target %q", c.Target)
+		}
+	case *BindBufferCmd:
+		rt := p.RenderTarget(c.SourceRT)
+		switch {
+		case c.Sampler == "":
+			return "no sampler"
+		case rt == nil:
+			return fmt.Sprintf("unknown render target %q", c.SourceRT)
+		case c.BufIndex == 32 && !rt.DepthBuf:
+			return fmt.Sprintf("render target %q has no depth buffer", c.SourceRT)
+		case c.BufIndex != 32 && (c.BufIndex < 0 || c.BufIndex >= rt.NumColBufs):
+			return fmt.Sprintf("render target %q has no color buffer %d", c.SourceRT, c.BufIndex)
+		}
+	case *DrawGeometryCmd:
+		if c.Context == "" {
+			return "no context"
+		}
+		if !validClass(c.Class) {
+			return fmt.Sprintf("invalid class %q", c.Class)
+		}
+		if !validOrder(c.Order) {
+			return fmt.Sprintf("unknown order %q", c.Order)
+		}
+	case *DoForwardLightLoopCmd:
+		if !validClass(c.Class) {
+			return fmt.Sprintf("invalid class %q", c.Class)
+		}
+		if !validOrder(c.Order) {
+			return fmt.Sprintf("unknown order %q", c.Order)
+		}
+	case *DrawOverlaysCmd:
+		if c.Context == "" {
+			return "no context"
+		}
+	case *DrawQuadCmd:
+		if c.Context == "" {
+			return "no context"
+		}
+		if err := materials.check(c.Material, ""); err != nil {
+			return err.Error()
+		}
+		if err := materials.checkContext(c.Material, c.Context); err != nil {
+			return err.Error()
+		}
+	case *SetUniformCmd:
+		if c.Uniform == "" {
+			return "no uniform"
+		}
+		if err := materials.check(c.Material, c.Uniform); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+// validClass checks a material class filter, an optional ~ followed by dot separated names
+func validClass(class string) bool {
+	class = strings.TrimPrefix(class, "~")
+	if class == "" {
+		return true
+	}
+	for _, part := range strings.Split(class, ".") {
+		if part == "" || strings.ContainsAny(part, " ~") {
+			return false
+		}
+	}
+	return true
+}
+
+func validOrder(order string) bool {
+	switch order {
+	case "", "NONE", "FRONT_TO_BACK", "BACK_TO_FRONT", "STATECHANGES":
+		return true
+	}
+	return false
+}
+
+// materialChecker reads referenced materials and their shaders once to check them
+type materialChecker struct {
+	fsys    fs.FS
+	descs   map[string]*MaterialDesc
+	shaders map[string]ShaderInfo
+}
+
+// check reports an error if the material can't be read or doesn't set uniform
+func (m *materialChecker) check(name string, uniform string) error {
+	if name == "" {
+		return errors.New("no material")
+	}
+	if m.fsys == nil {
+		return nil
+	}
+	desc, ok := m.descs[name]
+	if !ok {
+		data, err := readResource(m.fsys, ResTypes_Material, name)
+		if err == nil {
+			desc, err = DecodeMaterialDesc(bytes.NewReader(data))
+		}
+		if err == nil {
+			desc, err = desc.Resolve(m.fsys)
+		}
+		if err != nil {
+			return err
+		}
+		m.descs[name] = desc
+	}
+	if uniform != "" && desc.Uniform(uniform) == nil {
+		return fmt.Errorf("material %q does not set uniform %q", name, uniform)
+	}
+	return nil
+}
+
+// checkContext reports an error if the shader of the material, which must have been checked,
+// can't be read or doesn't declare context
+func (m *materialChecker) checkContext(name string, context string) error {
+	desc := m.descs[name]
+	if desc == nil {
+		return nil
+	}
+	if desc.Shader == "" {
+		return fmt.Errorf("material %q has no shader", name)
+	}
+	info, ok := m.shaders[desc.Shader]
+	if !ok {
+		data, err := readResource(m.fsys, ResTypes_Shader, desc.Shader)
+		if err != nil {
+			return err
+		}
+		effect, err := shader.Parse(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+		info = effect
+		m.shaders[desc.Shader] = info
+	}
+	if !info.HasContext(context) {
+		return fmt.Errorf("shader %q of material %q does not declare context %q", desc.Shader, name,
+			context)
+	}
+	return nil
+}
+
+// Create adds a pipeline resource with the given name and loads the description into it
+func (p *PipelineDesc) Create(name string, flags int) (Pipeline, error) {
+	var buf bytes.Buffer
+	if err := p.Encode(&buf); err != nil {
+		return Pipeline{}, err
+	}
+	r, err := createResource(ResTypes_Pipeline, name, flags, buf.Bytes())
+	return Pipeline{r}, err
+}
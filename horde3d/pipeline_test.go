@@ -0,0 +1,124 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+const contentDir = "../examples/content"
+
+var examplePipelines = []string{
+	"pipelines/hdr.pipeline.xml",
+	"pipelines/forward.pipeline.xml",
+	"pipelines/deferred.pipeline.xml",
+}
+
+func encodePipeline(t *testing.T, p *PipelineDesc) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := p.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestExamplePipelines(t *testing.T) {
+	fsys := os.DirFS(contentDir)
+	for _, name := range examplePipelines {
+		data, err := os.ReadFile(contentDir + "/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := DecodePipelineDesc(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := p.Validate(fsys); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+
+		encoded := encodePipeline(t, p)
+		again, err := DecodePipelineDesc(strings.NewReader(encoded))
+		if err != nil {
+			t.Fatalf("%s: decoding the encoded pipeline: %v", name, err)
+		}
+		if encodePipeline(t, again) != encoded {
+			t.Errorf("%s: encoding isn't stable:\n%s", name, encoded)
+		}
+		if len(again.Stages) != len(p.Stages) || len(again.RenderTargets) != len(p.RenderTargets) {
+			t.Errorf("%s: encoded pipeline has %d stages and %d targets instead of %d and %d", name,
+				len(again.Stages), len(again.RenderTargets), len(p.Stages), len(p.RenderTargets))
+		}
+		if err := again.Validate(fsys); err != nil {
+			t.Errorf("%s: encoded pipeline: %v", name, err)
+		}
+	}
+}
+
+func TestPipelineValidateErrors(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		build func(p *PipelineDesc)
+		want  string
+	}{
+		{"color buffer", func(p *PipelineDesc) {
+			p.AddStage("Blur").BindBuffer("buf0", "BLURBUF", 1)
+		}, `render target "BLURBUF" has no color buffer 1`},
+		{"depth buffer", func(p *PipelineDesc) {
+			p.AddStage("Blur").BindBuffer("depthBuf", "BLURBUF", 32)
+		}, `render target "BLURBUF" has no depth buffer`},
+		{"negative buffer", func(p *PipelineDesc) {
+			p.AddStage("Blur").BindBuffer("buf0", "BLURBUF", -1)
+		}, `no color buffer -1`},
+		{"switch target", func(p *PipelineDesc) {
+			p.AddStage("Blur").SwitchTarget("NOBUF")
+		}, `unknown render target "NOBUF"`},
+		{"bind target", func(p *PipelineDesc) {
+			p.AddStage("Blur").BindBuffer("buf0", "NOBUF", 0)
+		}, `unknown render target "NOBUF"`},
+		{"context", func(p *PipelineDesc) {
+			p.AddStage("Blur").DrawQuad("pipelines/postHDR.material.xml", "NOCONTEXT")
+		}, `does not declare context "NOCONTEXT"`},
+		{"uniform", func(p *PipelineDesc) {
+			p.AddStage("Blur").SetUniform("pipelines/postHDR.material.xml", "noUniform", 1, 0, 0, 0)
+		}, `does not set uniform "noUniform"`},
+		{"duplicate stage", func(p *PipelineDesc) {
+			p.AddStage("Blur")
+			p.AddStage("Blur")
+		}, `stage "Blur" is defined twice`},
+	} {
+		p := NewPipelineDesc()
+		p.AddRenderTarget("BLURBUF", "RGBA8", 1, false, 0.25)
+		test.build(p)
+		err := p.Validate(os.DirFS(contentDir))
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got %v, want %q", test.name, err, test.want)
+		}
+		if !errors.Is(err, ErrInvalidDesc) {
+			t.Errorf("%s: %v is not ErrInvalidDesc", test.name, err)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// reloadTypes are the resource types a HotReloader watches
+var reloadTypes = []int{
+	ResTypes_Shader,
+	ResTypes_Code,
+	ResTypes_Material,
+	ResTypes_Pipeline,
+	ResTypes_Texture,
+}
+
+// HotReloader watches a content file system during development and reloads shaders, code,
+// materials, pipelines and textures when their files change.  Files are read like
+// LoadResourcesFromFS reads them, through OpenResource if the file system is a ResourceFS.  When a new version fails to
+// load, the last good version is loaded again so rendering continues.  Resources added after
+// the HotReloader was created are tracked from the next Poll, a file that changes before then
+// has no good version to go back to.
+type HotReloader struct {
+	fsys fs.FS
+
+	// Viewport returns the size pipelines are resized to after being reloaded.  If nil, the
+	// render buffers of reloaded pipelines are left at their default size.
+	Viewport func() (width int, height int)
+
+	// Log receives the engine messages the reloader takes off the queue to check whether a
+	// shader compiled, so they still reach the application log.  If nil, they are only kept by
+	// the errors Poll returns.
+	Log *LogBridge
+
+	scanned bool
+	files   map[string]fileStamp
+	good    map[string][]byte
+}
+
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// NewHotReloader creates a HotReloader for fsys and records the current state of its files.
+// It must be called from the render thread after the resources have been loaded.
+func NewHotReloader(fsys fs.FS) (*HotReloader, error) {
+	r := &HotReloader{
+		fsys:  fsys,
+		files: make(map[string]fileStamp),
+		good:  make(map[string][]byte),
+	}
+	if _, err := r.scan(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Poll checks the file system for changed files, reloads the matching resources and returns the
+// ones that were reloaded.  It must be called from the render thread.  The returned error joins
+// a *ResourceLoadError for every resource that failed to reload.  Those are reverted to their
+// last good version, and the error says so if that fails too.
+func (r *HotReloader) Poll() ([]H3DRes, error) {
+	changed, err := r.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	var reloaded []H3DRes
+	var errs []error
+	for _, name := range changed {
+		for _, resType := range reloadTypes {
+			res := FindResource(resType, name)
+			if res == 0 {
+				continue
+			}
+			if err := r.reload(res, name); err != nil {
+				errs = append(errs, &ResourceLoadError{Name: name, Type: resType, Err: err})
+				continue
+			}
+			reloaded = append(reloaded, res)
+		}
+	}
+	return reloaded, errors.Join(errs...)
+}
+
+// scan walks the file system and returns the files that changed since the last scan
+func (r *HotReloader) scan() ([]string, error) {
+	first := !r.scanned
+	r.scanned = true
+	var changed []string
+	err := fs.WalkDir(r.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		stamp := fileStamp{modTime: info.ModTime(), size: info.Size()}
+		old, ok := r.files[name]
+		r.files[name] = stamp
+		if !first && ok && old != stamp {
+			changed = append(changed, name)
+			return nil
+		}
+		// keep the contents of watched resources so a failed reload can be reverted.  Files of
+		// resources added after the last scan are picked up here as long as they didn't change,
+		// the loaded version is what's on disk then.
+		if _, kept := r.good[name]; !kept {
+			if resType := r.watchedType(name); resType != ResTypes_Undefined {
+				if data, err := readResource(r.fsys, resType, name); err == nil {
+					r.good[name] = data
+				}
+			}
+		}
+		return nil
+	})
+	return changed, err
+}
+
+// watchedType returns the type of the watched resource named name, or ResTypes_Undefined
+func (r *HotReloader) watchedType(name string) int {
+	for _, resType := range reloadTypes {
+		if FindResource(resType, name) != 0 {
+			return resType
+		}
+	}
+	return ResTypes_Undefined
+}
+
+func (r *HotReloader) reload(res H3DRes, name string) error {
+	data, err := readResource(r.fsys, res.Type(), name)
+	if err == nil {
+		err = r.load(res, data)
+	}
+	if err != nil {
+		if good, ok := r.good[name]; ok {
+			if restoreErr := r.load(res, good); restoreErr != nil {
+				err = fmt.Errorf("%w, and restoring the last good version failed: %w", err, restoreErr)
+				if r.Log != nil {
+					r.Log.Forward(context.Background(), []LogMessage{{Level: LogLevel_Error,
+						Text: fmt.Sprintf("hot reload of %s: %v", name, err)}})
+				}
+			}
+		}
+		return err
+	}
+	r.good[name] = data
+	return nil
+}
+
+func (r *HotReloader) load(res H3DRes, data []byte) error {
+	res.Unload()
+	if err := res.LoadErr(data); err != nil {
+		return err
+	}
+
+	switch res.Type() {
+	case ResTypes_Shader, ResTypes_Code:
+		// shaders compile while loading, and a failed compilation still leaves the resource loaded
+		msgs := drainMessages()
+		if r.Log != nil {
+			r.Log.Forward(context.Background(), msgs)
+		}
+		if kind := classifyMessages(msgs, nil); kind == ErrShaderCompile {
+			return &EngineError{Op: "Load", Kind: kind, Messages: msgs}
+		}
+	case ResTypes_Pipeline:
+		if r.Viewport != nil {
+			width, height := r.Viewport()
+			ResizePipelineBuffers(res, width, height)
+		}
+	}
+	return nil
+}
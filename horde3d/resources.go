@@ -0,0 +1,535 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+/*
+#include "goHorde3D.h"
+#include <stdlib.h>
+*/
+import "C"
+import (
+	"fmt"
+	"slices"
+	"unsafe"
+
+	"bitbucket.org/tshannon/gohorde/horde3d/format/geo"
+	"bitbucket.org/tshannon/gohorde/horde3d/math"
+)
+
+// resource holds the operations shared by all of the typed resource handles
+type resource struct {
+	res H3DRes
+}
+
+func asResource(res H3DRes, resType int) (resource, error) {
+	if res == 0 {
+		return resource{}, ErrInvalidHandle
+	}
+	if t := res.Type(); t != resType {
+		return resource{}, fmt.Errorf("%w: %s is a %s resource, not a %s resource", ErrWrongType,
+			res.Name(), resTypeName(t), resTypeName(resType))
+	}
+	return resource{res}, nil
+}
+
+func resTypeName(resType int) string {
+	switch resType {
+	case ResTypes_SceneGraph:
+		return "SceneGraph"
+	case ResTypes_Geometry:
+		return "Geometry"
+	case ResTypes_Animation:
+		return "Animation"
+	case ResTypes_Material:
+		return "Material"
+	case ResTypes_Code:
+		return "Code"
+	case ResTypes_Shader:
+		return "Shader"
+	case ResTypes_Texture:
+		return "Texture"
+	case ResTypes_ParticleEffect:
+		return "ParticleEffect"
+	case ResTypes_Pipeline:
+		return "Pipeline"
+	}
+	return "Undefined"
+}
+
+// Res returns the untyped resource handle
+func (r resource) Res() H3DRes {
+	return r.res
+}
+
+func (r resource) Name() string {
+	return r.res.Name()
+}
+
+func (r resource) IsLoaded() bool {
+	return r.res.IsLoaded()
+}
+
+func (r resource) Load(data []byte) error {
+	return r.res.LoadErr(data)
+}
+
+func (r resource) Unload() {
+	r.res.Unload()
+}
+
+func (r resource) Remove() int {
+	return r.res.Remove()
+}
+
+type Pipeline struct{ resource }
+type Material struct{ resource }
+type Texture struct{ resource }
+type Geometry struct{ resource }
+type Animation struct{ resource }
+type Shader struct{ resource }
+type ParticleEffect struct{ resource }
+type SceneGraph struct{ resource }
+
+func AsPipeline(res H3DRes) (Pipeline, error) {
+	r, err := asResource(res, ResTypes_Pipeline)
+	return Pipeline{r}, err
+}
+
+func AsMaterial(res H3DRes) (Material, error) {
+	r, err := asResource(res, ResTypes_Material)
+	return Material{r}, err
+}
+
+func AsTexture(res H3DRes) (Texture, error) {
+	r, err := asResource(res, ResTypes_Texture)
+	return Texture{r}, err
+}
+
+func AsGeometry(res H3DRes) (Geometry, error) {
+	r, err := asResource(res, ResTypes_Geometry)
+	return Geometry{r}, err
+}
+
+func AsAnimation(res H3DRes) (Animation, error) {
+	r, err := asResource(res, ResTypes_Animation)
+	return Animation{r}, err
+}
+
+func AsShader(res H3DRes) (Shader, error) {
+	r, err := asResource(res, ResTypes_Shader)
+	return Shader{r}, err
+}
+
+func AsParticleEffect(res H3DRes) (ParticleEffect, error) {
+	r, err := asResource(res, ResTypes_ParticleEffect)
+	return ParticleEffect{r}, err
+}
+
+func AsSceneGraph(res H3DRes) (SceneGraph, error) {
+	r, err := asResource(res, ResTypes_SceneGraph)
+	return SceneGraph{r}, err
+}
+
+func AddPipeline(name string, flags int) Pipeline {
+	return Pipeline{resource{AddResource(ResTypes_Pipeline, name, flags)}}
+}
+
+func AddMaterial(name string, flags int) Material {
+	return Material{resource{AddResource(ResTypes_Material, name, flags)}}
+}
+
+func AddTexture(name string, flags int) Texture {
+	return Texture{resource{AddResource(ResTypes_Texture, name, flags)}}
+}
+
+func AddGeometry(name string, flags int) Geometry {
+	return Geometry{resource{AddResource(ResTypes_Geometry, name, flags)}}
+}
+
+func AddAnimation(name string, flags int) Animation {
+	return Animation{resource{AddResource(ResTypes_Animation, name, flags)}}
+}
+
+func AddShader(name string, flags int) Shader {
+	return Shader{resource{AddResource(ResTypes_Shader, name, flags)}}
+}
+
+func AddParticleEffect(name string, flags int) ParticleEffect {
+	return ParticleEffect{resource{AddResource(ResTypes_ParticleEffect, name, flags)}}
+}
+
+func AddSceneGraph(name string, flags int) SceneGraph {
+	return SceneGraph{resource{AddResource(ResTypes_SceneGraph, name, flags)}}
+}
+
+// createResource adds a resource of resType and loads data into it.  It fails if a resource of
+// the same name has already been loaded.
+func createResource(resType int, name string, flags int, data []byte) (resource, error) {
+	res := AddResource(resType, name, flags)
+	if res == 0 {
+		return resource{}, newEngineError("AddResource", ErrFailed)
+	}
+	// AddResource returns the existing resource with a new reference, drop it again on failure
+	if res.IsLoaded() {
+		res.Remove()
+		return resource{}, fmt.Errorf("%w: %s %q is already loaded", ErrResourceLoad,
+			resTypeName(resType), name)
+	}
+	if err := res.LoadErr(data); err != nil {
+		res.Remove()
+		return resource{}, err
+	}
+	return resource{res}, nil
+}
+
+// Pipeline
+
+func (p Pipeline) ResizeBuffers(width int, height int) {
+	ResizePipelineBuffers(p.res, width, height)
+}
+
+// RenderTargetData reads back the RGBA float pixels of a buffer of the named render target
+func (p Pipeline) RenderTargetData(targetName string, bufIndex int) (width int, height int,
+	compCount int, data []float32, err error) {
+	cTargetName := C.CString(targetName)
+	defer C.free(unsafe.Pointer(cTargetName))
+
+	var w, h, comps C.int
+	if C.h3dGetRenderTargetData(C.H3DRes(p.res), cTargetName, C.int(bufIndex), &w, &h, &comps,
+		nil, 0) == 0 {
+		return 0, 0, 0, nil, newEngineError("RenderTargetData", ErrNotFound)
+	}
+
+	data = make([]float32, int(w)*int(h)*int(comps))
+	if len(data) > 0 {
+		if C.h3dGetRenderTargetData(C.H3DRes(p.res), cTargetName, C.int(bufIndex), nil, nil, nil,
+			unsafe.Pointer(&data[0]), C.int(len(data)*4)) == 0 {
+			return 0, 0, 0, nil, newEngineError("RenderTargetData", ErrFailed)
+		}
+	}
+	return int(w), int(h), int(comps), data, nil
+}
+
+func (p Pipeline) StageCount() int {
+	return p.res.ElemCount(PipeRes_StageElem)
+}
+
+func (p Pipeline) StageName(index int) string {
+	return p.res.ResParamStr(PipeRes_StageElem, index, PipeRes_StageNameStr)
+}
+
+// FindStage returns the index of the named stage or -1 if the pipeline doesn't have it
+func (p Pipeline) FindStage(name string) int {
+	return p.res.FindResElem(PipeRes_StageElem, PipeRes_StageNameStr, name)
+}
+
+func (p Pipeline) StageActive(index int) bool {
+	return p.res.ResParamI(PipeRes_StageElem, index, PipeRes_StageActivationI) != 0
+}
+
+func (p Pipeline) SetStageActive(index int, active bool) {
+	p.res.SetResParamI(PipeRes_StageElem, index, PipeRes_StageActivationI, int(Int[active]))
+}
+
+// Material
+
+func (m Material) SetUniform(name string, a float32, b float32, c float32, d float32) error {
+	return SetMaterialUniformErr(m.res, name, a, b, c, d)
+}
+
+func (m Material) Uniform(name string) ([4]float32, error) {
+	var value [4]float32
+	idx := m.res.FindResElem(MatRes_UniformElem, MatRes_UnifNameStr, name)
+	if idx < 0 {
+		return value, fmt.Errorf("%w: uniform %s in %s", ErrNotFound, name, m.Name())
+	}
+	for i := range value {
+		value[i] = m.res.ResParamF(MatRes_UniformElem, idx, MatRes_UnifValueF4, i)
+	}
+	return value, nil
+}
+
+func (m Material) SetSampler(name string, texture Texture) error {
+	idx := m.res.FindResElem(MatRes_SamplerElem, MatRes_SampNameStr, name)
+	if idx < 0 {
+		return fmt.Errorf("%w: sampler %s in %s", ErrNotFound, name, m.Name())
+	}
+	m.res.SetResParamI(MatRes_SamplerElem, idx, MatRes_SampTexResI, int(texture.res))
+	return nil
+}
+
+func (m Material) Sampler(name string) (Texture, error) {
+	idx := m.res.FindResElem(MatRes_SamplerElem, MatRes_SampNameStr, name)
+	if idx < 0 {
+		return Texture{}, fmt.Errorf("%w: sampler %s in %s", ErrNotFound, name, m.Name())
+	}
+	return Texture{resource{H3DRes(m.res.ResParamI(MatRes_SamplerElem, idx, MatRes_SampTexResI))}}, nil
+}
+
+func (m Material) Class() string {
+	return m.res.ResParamStr(MatRes_MaterialElem, 0, MatRes_MatClassStr)
+}
+
+func (m Material) SetClass(class string) {
+	m.res.SetResParamStr(MatRes_MaterialElem, 0, MatRes_MatClassStr, class)
+}
+
+func (m Material) Shader() Shader {
+	return Shader{resource{H3DRes(m.res.ResParamI(MatRes_MaterialElem, 0, MatRes_MatShaderI))}}
+}
+
+func (m Material) SetShader(shader Shader) {
+	m.res.SetResParamI(MatRes_MaterialElem, 0, MatRes_MatShaderI, int(shader.res))
+}
+
+func (m Material) Link() Material {
+	return Material{resource{H3DRes(m.res.ResParamI(MatRes_MaterialElem, 0, MatRes_MatLinkI))}}
+}
+
+func (m Material) SetLink(link Material) {
+	m.res.SetResParamI(MatRes_MaterialElem, 0, MatRes_MatLinkI, int(link.res))
+}
+
+// Texture
+
+// Size returns the size of the base image of the texture
+func (t Texture) Size() (width int, height int) {
+	return t.res.ResParamI(TexRes_ImageElem, 0, TexRes_ImgWidthI),
+		t.res.ResParamI(TexRes_ImageElem, 0, TexRes_ImgHeightI)
+}
+
+func (t Texture) Format() int {
+	return t.res.ResParamI(TexRes_TextureElem, 0, TexRes_TexFormatI)
+}
+
+func (t Texture) SliceCount() int {
+	return t.res.ResParamI(TexRes_TextureElem, 0, TexRes_TexSliceCountI)
+}
+
+// Geometry
+
+func (g Geometry) VertexCount() int {
+	return g.res.ResParamI(GeoRes_GeometryElem, 0, GeoRes_GeoVertexCountI)
+}
+
+func (g Geometry) IndexCount() int {
+	return g.res.ResParamI(GeoRes_GeometryElem, 0, GeoRes_GeoIndexCountI)
+}
+
+// Indices16 reports whether the index data is stored as uint16 rather than uint32
+func (g Geometry) Indices16() bool {
+	return g.res.ResParamI(GeoRes_GeometryElem, 0, GeoRes_GeoIndices16I) != 0
+}
+
+// CreateGeometry creates a geometry resource from g through CreateGeometryRes.  Only the
+// streams CreateGeometryRes takes are used, g must not have joints or morph targets.
+func CreateGeometry(name string, g *geo.Geometry) (Geometry, error) {
+	invalid := func(format string, args ...any) (Geometry, error) {
+		return Geometry{}, fmt.Errorf("%w: geometry %q: %s", ErrInvalidDesc, name,
+			fmt.Sprintf(format, args...))
+	}
+	switch {
+	case g.VertexCount == 0 || len(g.Indices) == 0:
+		return invalid("no vertices or no indices")
+	case len(g.Joints) > 0 || len(g.MorphTargets) > 0:
+		return invalid("joints and morph targets are not supported")
+	case len(g.Indices)%3 != 0:
+		return invalid("%d indices don't make whole triangles", len(g.Indices))
+	case len(g.Tangents) > 0 && len(g.Bitangents) == 0:
+		return invalid("tangents without bitangents")
+	}
+	// the engine reads VertexCount elements of every stream it gets, empty streams are left out
+	for _, stream := range []struct {
+		name string
+		len  int
+	}{
+		{"positions", len(g.Positions)},
+		{"normals", len(g.Normals)},
+		{"tangents", len(g.Tangents)},
+		{"bitangents", len(g.Bitangents)},
+		{"texture coordinates 0", len(g.TexCoords0)},
+		{"texture coordinates 1", len(g.TexCoords1)},
+	} {
+		if (stream.len > 0 || stream.name == "positions") && stream.len != g.VertexCount {
+			return invalid("%d %s for %d vertices", stream.len, stream.name, g.VertexCount)
+		}
+	}
+	for i, index := range g.Indices {
+		if int(index) >= g.VertexCount {
+			return invalid("index %d is %d, there are %d vertices", i, index, g.VertexCount)
+		}
+	}
+
+	pos := make([]float32, 0, 3*len(g.Positions))
+	for _, p := range g.Positions {
+		pos = append(pos, p.X, p.Y, p.Z)
+	}
+	flatten := func(vs [][3]int16) []int16 {
+		if len(vs) == 0 {
+			return nil
+		}
+		out := make([]int16, 0, 3*len(vs))
+		for _, v := range vs {
+			out = append(out, v[:]...)
+		}
+		return out
+	}
+	texCoords := func(uvs []math.Vec2) []float32 {
+		if len(uvs) == 0 {
+			return nil
+		}
+		out := make([]float32, 0, 2*len(uvs))
+		for _, uv := range uvs {
+			out = append(out, uv.X, uv.Y)
+		}
+		return out
+	}
+
+	res := CreateGeometryRes(name, g.VertexCount, len(g.Indices), pos, g.Indices,
+		flatten(g.Normals), flatten(g.Tangents), flatten(g.Bitangents), texCoords(g.TexCoords0),
+		texCoords(g.TexCoords1))
+	if res == 0 {
+		return Geometry{}, newEngineError("CreateGeometryRes", ErrFailed)
+	}
+	return Geometry{resource{res}}, nil
+}
+
+// Animation
+
+// EntityCount returns the number of animated joints and meshes
+func (a Animation) EntityCount() int {
+	return a.res.ElemCount(AnimRes_EntityElem)
+}
+
+// FrameCount returns the largest number of frames stored for any entity of the animation
+func (a Animation) FrameCount() int {
+	frames := 0
+	for i := 0; i < a.EntityCount(); i++ {
+		if f := a.res.ResParamI(AnimRes_EntityElem, i, AnimRes_EntFrameCountI); f > frames {
+			frames = f
+		}
+	}
+	return frames
+}
+
+// Shader
+
+func (s Shader) ContextCount() int {
+	return s.res.ElemCount(ShaderRes_ContextElem)
+}
+
+func (s Shader) ContextName(index int) string {
+	return s.res.ResParamStr(ShaderRes_ContextElem, index, ShaderRes_ContNameStr)
+}
+
+func (s Shader) SamplerNames() []string {
+	names := make([]string, s.res.ElemCount(ShaderRes_SamplerElem))
+	for i := range names {
+		names[i] = s.res.ResParamStr(ShaderRes_SamplerElem, i, ShaderRes_SampNameStr)
+	}
+	return names
+}
+
+func (s Shader) UniformNames() []string {
+	names := make([]string, s.res.ElemCount(ShaderRes_UniformElem))
+	for i := range names {
+		names[i] = s.res.ResParamStr(ShaderRes_UniformElem, i, ShaderRes_UnifNameStr)
+	}
+	return names
+}
+
+// HasSampler reports whether the shader declares the sampler
+func (s Shader) HasSampler(name string) bool {
+	return slices.Contains(s.SamplerNames(), name)
+}
+
+// HasUniform reports whether the shader declares the uniform
+func (s Shader) HasUniform(name string) bool {
+	return slices.Contains(s.UniformNames(), name)
+}
+
+// HasContext reports whether the shader declares the context
+func (s Shader) HasContext(name string) bool {
+	for i := 0; i < s.ContextCount(); i++ {
+		if s.ContextName(i) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// UniformDefault returns the default value of the named uniform and its number of components
+func (s Shader) UniformDefault(name string) (value [4]float32, size int, err error) {
+	idx := s.res.FindResElem(ShaderRes_UniformElem, ShaderRes_UnifNameStr, name)
+	if idx < 0 {
+		return value, 0, fmt.Errorf("%w: uniform %s in %s", ErrNotFound, name, s.Name())
+	}
+	for i := range value {
+		value[i] = s.res.ResParamF(ShaderRes_UniformElem, idx, ShaderRes_UnifDefValueF4, i)
+	}
+	return value, s.res.ResParamI(ShaderRes_UniformElem, idx, ShaderRes_UnifSizeI), nil
+}
+
+func (s Shader) SetUniformDefault(name string, a float32, b float32, c float32, d float32) error {
+	idx := s.res.FindResElem(ShaderRes_UniformElem, ShaderRes_UnifNameStr, name)
+	if idx < 0 {
+		return fmt.Errorf("%w: uniform %s in %s", ErrNotFound, name, s.Name())
+	}
+	for i, v := range [4]float32{a, b, c, d} {
+		s.res.SetResParamF(ShaderRes_UniformElem, idx, ShaderRes_UnifDefValueF4, i, v)
+	}
+	return nil
+}
+
+// ParticleEffect
+
+// ParticleChannel holds the over life settings of one ParticleEffect channel
+type ParticleChannel struct {
+	StartMin float32
+	StartMax float32
+	EndRate  float32
+}
+
+// Life returns the range the random life time of a particle is picked from, in seconds
+func (p ParticleEffect) Life() (lifeMin float32, lifeMax float32) {
+	return p.res.ResParamF(PartEffRes_ParticleElem, 0, PartEffRes_PartLifeMinF, 0),
+		p.res.ResParamF(PartEffRes_ParticleElem, 0, PartEffRes_PartLifeMaxF, 0)
+}
+
+func (p ParticleEffect) SetLife(lifeMin float32, lifeMax float32) {
+	p.res.SetResParamF(PartEffRes_ParticleElem, 0, PartEffRes_PartLifeMinF, 0, lifeMin)
+	p.res.SetResParamF(PartEffRes_ParticleElem, 0, PartEffRes_PartLifeMaxF, 0, lifeMax)
+}
+
+// Channel returns the settings of a channel, channelElem is one of the PartEffRes_Chan*Elem values
+func (p ParticleEffect) Channel(channelElem int) ParticleChannel {
+	return ParticleChannel{
+		StartMin: p.res.ResParamF(channelElem, 0, PartEffRes_ChanStartMinF, 0),
+		StartMax: p.res.ResParamF(channelElem, 0, PartEffRes_ChanStartMaxF, 0),
+		EndRate:  p.res.ResParamF(channelElem, 0, PartEffRes_ChanEndRateF, 0),
+	}
+}
+
+func (p ParticleEffect) SetChannel(channelElem int, channel ParticleChannel) {
+	p.res.SetResParamF(channelElem, 0, PartEffRes_ChanStartMinF, 0, channel.StartMin)
+	p.res.SetResParamF(channelElem, 0, PartEffRes_ChanStartMaxF, 0, channel.StartMax)
+	p.res.SetResParamF(channelElem, 0, PartEffRes_ChanEndRateF, 0, channel.EndRate)
+}
@@ -0,0 +1,135 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import "bitbucket.org/tshannon/gohorde/horde3d/math"
+
+// The spatial types are those of the math package, so node transformations can be used with
+// its operations directly
+type (
+	Vec3 = math.Vec3
+	Quat = math.Quat
+	// Mat4 is stored in column major order, the layout used by TransMats and SetNodeTransMat
+	Mat4 = math.Mat4
+)
+
+type AABB struct {
+	Min, Max Vec3
+}
+
+type Ray struct {
+	Origin, Direction Vec3
+}
+
+// RayHit is an intersection found by CastRay
+type RayHit struct {
+	Node     H3DNode
+	Distance float32
+	Point    Vec3
+}
+
+// Position returns the translation of the node relative to its parent
+func (node H3DNode) Position() Vec3 {
+	var t, r, s Vec3
+	node.Transform(&t.X, &t.Y, &t.Z, &r.X, &r.Y, &r.Z, &s.X, &s.Y, &s.Z)
+	return t
+}
+
+// Rotation returns the Euler angles of the node relative to its parent, in degrees
+func (node H3DNode) Rotation() Vec3 {
+	var t, r, s Vec3
+	node.Transform(&t.X, &t.Y, &t.Z, &r.X, &r.Y, &r.Z, &s.X, &s.Y, &s.Z)
+	return r
+}
+
+func (node H3DNode) Scale() Vec3 {
+	var t, r, s Vec3
+	node.Transform(&t.X, &t.Y, &t.Z, &r.X, &r.Y, &r.Z, &s.X, &s.Y, &s.Z)
+	return s
+}
+
+// SetTRS sets translation, Euler rotation in degrees and scale of the node relative to its parent
+func (node H3DNode) SetTRS(translation Vec3, rotation Vec3, scale Vec3) {
+	node.SetTransform(translation.X, translation.Y, translation.Z, rotation.X, rotation.Y, rotation.Z,
+		scale.X, scale.Y, scale.Z)
+}
+
+func (node H3DNode) SetPosition(position Vec3) {
+	var t, r, s Vec3
+	node.Transform(&t.X, &t.Y, &t.Z, &r.X, &r.Y, &r.Z, &s.X, &s.Y, &s.Z)
+	node.SetTRS(position, r, s)
+}
+
+func (node H3DNode) SetRotation(rotation Vec3) {
+	var t, r, s Vec3
+	node.Transform(&t.X, &t.Y, &t.Z, &r.X, &r.Y, &r.Z, &s.X, &s.Y, &s.Z)
+	node.SetTRS(t, rotation, s)
+}
+
+func (node H3DNode) SetScale(scale Vec3) {
+	var t, r, s Vec3
+	node.Transform(&t.X, &t.Y, &t.Z, &r.X, &r.Y, &r.Z, &s.X, &s.Y, &s.Z)
+	node.SetTRS(t, r, scale)
+}
+
+// LocalMatrix returns the transformation of the node relative to its parent
+func (node H3DNode) LocalMatrix() Mat4 {
+	var m [16]float32
+	node.TransMats(&m, nil)
+	return Mat4(m)
+}
+
+// WorldMatrix returns the absolute transformation of the node
+func (node H3DNode) WorldMatrix() Mat4 {
+	var m [16]float32
+	node.TransMats(nil, &m)
+	return Mat4(m)
+}
+
+func (node H3DNode) SetLocalMatrix(m Mat4) {
+	mat := [16]float32(m)
+	node.SetNodeTransMat(&mat)
+}
+
+// Orientation returns the rotation of the node relative to its parent as a quaternion
+func (node H3DNode) Orientation() Quat {
+	return node.LocalMatrix().Quat()
+}
+
+// SetOrientation replaces the rotation of the node relative to its parent, keeping translation
+// and scale
+func (node H3DNode) SetOrientation(q Quat) {
+	t, s := math.Mat4Translate(node.Position()), math.Mat4Scale(node.Scale())
+	node.SetLocalMatrix(t.Mul(math.Mat4FromQuat(q)).Mul(s))
+}
+
+// Bounds returns the world space bounding box of the node
+func (node H3DNode) Bounds() AABB {
+	var b AABB
+	node.AABB(&b.Min.X, &b.Min.Y, &b.Min.Z, &b.Max.X, &b.Max.Y, &b.Max.Z)
+	return b
+}
+
+func (c CameraNode) Projection() Mat4 {
+	var m [16]float32
+	GetCameraProjMat(c.H3DNode, &m)
+	return Mat4(m)
+}
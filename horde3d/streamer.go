@@ -0,0 +1,192 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"time"
+)
+
+// Progress is reported by a Streamer after every loaded resource.  Total grows as loading
+// resources adds the resources they reference.
+type Progress struct {
+	Loaded int
+	Total  int
+	Bytes  int64
+}
+
+// StreamOptions configures a Streamer
+type StreamOptions struct {
+	Workers     int           // number of concurrent file reads, defaults to 4
+	FrameBudget time.Duration // time Update may spend loading per frame, 0 means no limit
+	Progress    func(Progress)
+}
+
+// Streamer loads the unloaded resources from a file system without blocking the render loop.
+// Files are read by background goroutines, through OpenResource if the file system is a
+// ResourceFS, while the resources are loaded on the render thread by calling Update once per
+// frame.  Resources are loaded in the order they were added, so a resource is always loaded
+// after the one that referenced it.
+type Streamer struct {
+	ctx    context.Context
+	fsys   fs.FS
+	opts   StreamOptions
+	sem    chan struct{}
+	queue  []*streamItem
+	queued map[H3DRes]bool
+
+	progress Progress
+	errs     []error
+	done     bool
+	err      error
+}
+
+type streamItem struct {
+	res     H3DRes
+	resType int
+	name    string
+	ready   chan struct{}
+	data    []byte
+	err     error
+}
+
+// NewStreamer creates a Streamer reading from fsys.  Cancelling ctx stops the streamer, leaving
+// the resources that weren't loaded yet unloaded.
+func NewStreamer(ctx context.Context, fsys fs.FS, opts StreamOptions) *Streamer {
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	return &Streamer{
+		ctx:    ctx,
+		fsys:   fsys,
+		opts:   opts,
+		sem:    make(chan struct{}, opts.Workers),
+		queued: make(map[H3DRes]bool),
+	}
+}
+
+// Update loads the resources whose files have been read, until the frame budget is used up, and
+// starts reading the resources that were added since the last call.  It must be called from the
+// render thread, and returns true once there is nothing left to load or the context is done.
+func (s *Streamer) Update() bool {
+	if s.done {
+		return true
+	}
+
+	s.schedule()
+	start := time.Now()
+	for {
+		if err := s.ctx.Err(); err != nil {
+			s.finish(err)
+			return true
+		}
+		if len(s.queue) == 0 {
+			s.finish(nil)
+			return true
+		}
+		item := s.queue[0]
+		select {
+		case <-item.ready:
+		default:
+			return false
+		}
+		s.queue = s.queue[1:]
+		s.load(item)
+		// loading may have added referenced resources
+		s.schedule()
+		if s.opts.FrameBudget > 0 && time.Since(start) >= s.opts.FrameBudget {
+			return false
+		}
+	}
+}
+
+// Done reports whether the streamer has finished
+func (s *Streamer) Done() bool {
+	return s.done
+}
+
+// Err returns the context error if the streamer was cancelled, otherwise the joined
+// *ResourceLoadError of every resource that failed.  It is nil until the streamer is done.
+func (s *Streamer) Err() error {
+	return s.err
+}
+
+// Progress returns the current progress
+func (s *Streamer) Progress() Progress {
+	return s.progress
+}
+
+func (s *Streamer) schedule() {
+	for i := 0; ; i++ {
+		res := QueryUnloadedResource(i)
+		if res == 0 {
+			return
+		}
+		if s.queued[res] {
+			continue
+		}
+		s.queued[res] = true
+		item := &streamItem{res: res, resType: res.Type(), name: res.Name(), ready: make(chan struct{})}
+		s.queue = append(s.queue, item)
+		s.progress.Total++
+		go s.read(item)
+	}
+}
+
+func (s *Streamer) read(item *streamItem) {
+	defer close(item.ready)
+	select {
+	case s.sem <- struct{}{}:
+	case <-s.ctx.Done():
+		item.err = s.ctx.Err()
+		return
+	}
+	defer func() { <-s.sem }()
+	item.data, item.err = readResource(s.fsys, item.resType, item.name)
+}
+
+func (s *Streamer) load(item *streamItem) {
+	err := item.err
+	if err != nil {
+		item.res.Load(nil)
+	} else {
+		err = item.res.LoadErr(item.data)
+		s.progress.Bytes += int64(len(item.data))
+	}
+	if err != nil {
+		s.errs = append(s.errs, &ResourceLoadError{Name: item.name, Type: item.resType, Err: err})
+	}
+	s.progress.Loaded++
+	if s.opts.Progress != nil {
+		s.opts.Progress(s.progress)
+	}
+}
+
+func (s *Streamer) finish(err error) {
+	s.done = true
+	if err != nil {
+		s.err = err
+		return
+	}
+	s.err = errors.Join(s.errs...)
+}
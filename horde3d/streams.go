@@ -0,0 +1,175 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+)
+
+var ErrStreamNotSupported = errors.New("horde3d: stream mapping not supported")
+
+// Floats per vertex in the geometry streams
+const (
+	geoPosFloats    = 3  // x, y, z
+	geoTanFloats    = 7  // nx, ny, nz, tx, ty, tz, tw
+	geoStaticFloats = 12 // u0, v0, 4 joint indices, 4 joint weights, u1, v1
+)
+
+// mapStream maps a resource stream of count elements, hands it to fn and unmaps it again, even
+// if fn panics.  The slice must not be kept after fn returns.
+func mapStream[T any](res H3DRes, elem int, elemIdx int, stream int, read bool, write bool,
+	count int, fn func([]T)) error {
+	if !read && !write {
+		return fmt.Errorf("%w: neither read nor write access requested", ErrStreamNotSupported)
+	}
+	if count <= 0 {
+		return fmt.Errorf("%w: %s has no data in the requested stream", ErrStreamNotSupported, res.Name())
+	}
+
+	ptr := res.MapResStream(elem, elemIdx, stream, read, write)
+	if ptr == nil {
+		return newEngineError("MapResStream", ErrFailed)
+	}
+	defer res.UnmapResStream()
+
+	fn(unsafe.Slice((*T)(ptr), count))
+	return nil
+}
+
+// MapIndices16 maps the triangle indices of a geometry stored with 16 bit indices
+func (g Geometry) MapIndices16(read bool, write bool, fn func(indices []uint16)) error {
+	if !g.Indices16() {
+		return fmt.Errorf("%w: %s has 32 bit indices", ErrStreamNotSupported, g.Name())
+	}
+	return mapStream(g.res, GeoRes_GeometryElem, 0, GeoRes_GeoIndexStream, read, write,
+		g.IndexCount(), fn)
+}
+
+// MapIndices32 maps the triangle indices of a geometry stored with 32 bit indices
+func (g Geometry) MapIndices32(read bool, write bool, fn func(indices []uint32)) error {
+	if g.Indices16() {
+		return fmt.Errorf("%w: %s has 16 bit indices", ErrStreamNotSupported, g.Name())
+	}
+	return mapStream(g.res, GeoRes_GeometryElem, 0, GeoRes_GeoIndexStream, read, write,
+		g.IndexCount(), fn)
+}
+
+// MapPositions maps the vertex positions as x, y, z triples
+func (g Geometry) MapPositions(read bool, write bool, fn func(positions []float32)) error {
+	return mapStream(g.res, GeoRes_GeometryElem, 0, GeoRes_GeoVertPosStream, read, write,
+		g.VertexCount()*geoPosFloats, fn)
+}
+
+// MapTangents maps the vertex tangent frames as nx, ny, nz, tx, ty, tz, tw groups
+func (g Geometry) MapTangents(read bool, write bool, fn func(tangents []float32)) error {
+	return mapStream(g.res, GeoRes_GeometryElem, 0, GeoRes_GeoVertTanStream, read, write,
+		g.VertexCount()*geoTanFloats, fn)
+}
+
+// MapStatic maps the static vertex attributes as groups of u0, v0, four joint indices, four joint
+// weights, u1, v1
+func (g Geometry) MapStatic(read bool, write bool, fn func(attributes []float32)) error {
+	return mapStream(g.res, GeoRes_GeometryElem, 0, GeoRes_GeoVertStaticStream, read, write,
+		g.VertexCount()*geoStaticFloats, fn)
+}
+
+// ImageCount returns the number of images of the texture, every slice has one image per mipmap
+// level
+func (t Texture) ImageCount() int {
+	return t.res.ElemCount(TexRes_ImageElem)
+}
+
+// ImageSize returns the size of an image of the texture
+func (t Texture) ImageSize(image int) (width int, height int) {
+	return t.res.ResParamI(TexRes_ImageElem, image, TexRes_ImgWidthI),
+		t.res.ResParamI(TexRes_ImageElem, image, TexRes_ImgHeightI)
+}
+
+// bytesPerPixel returns the size of a pixel of a mapped image, half floats are converted to
+// floats by the engine.  Compressed formats return 0.
+func bytesPerPixel(format int) int {
+	switch format {
+	case Formats_TEX_BGRA8:
+		return 4
+	case Formats_TEX_RGBA16F, Formats_TEX_RGBA32F:
+		return 16
+	}
+	return 0
+}
+
+// MapImage maps the pixels of an image of the texture, starting with the lower left corner.
+// BGRA8 images have 4 bytes per pixel, float images 16 bytes.  Compressed textures can't be
+// mapped and half float textures can only be read, since the engine converts them to float.
+func (t Texture) MapImage(image int, read bool, write bool, fn func(pixels []byte)) error {
+	format := t.Format()
+	bpp := bytesPerPixel(format)
+	if bpp == 0 {
+		return fmt.Errorf("%w: %s is compressed", ErrStreamNotSupported, t.Name())
+	}
+	if write && format == Formats_TEX_RGBA16F {
+		return fmt.Errorf("%w: %s is a half float texture and can only be read", ErrStreamNotSupported,
+			t.Name())
+	}
+	if image < 0 || image >= t.ImageCount() {
+		return fmt.Errorf("%w: %s has no image %d", ErrNotFound, t.Name(), image)
+	}
+
+	width, height := t.ImageSize(image)
+	return mapStream(t.res, TexRes_ImageElem, image, TexRes_ImgPixelStream, read, write,
+		width*height*bpp, fn)
+}
+
+// mappedSize estimates the memory of the data that can be mapped from a loaded resource.
+// Compressed textures are counted at their compressed size.
+func mappedSize(res H3DRes) int64 {
+	if !res.IsLoaded() {
+		return 0
+	}
+	switch res.Type() {
+	case ResTypes_Geometry:
+		g := Geometry{resource{res}}
+		indexSize := 4
+		if g.Indices16() {
+			indexSize = 2
+		}
+		vertexSize := 4 * (geoPosFloats + geoTanFloats + geoStaticFloats)
+		return int64(g.VertexCount()*vertexSize + g.IndexCount()*indexSize)
+	case ResTypes_Texture:
+		t := Texture{resource{res}}
+		format := t.Format()
+		var size int64
+		for i := 0; i < t.ImageCount(); i++ {
+			width, height := t.ImageSize(i)
+			switch format {
+			case Formats_TEX_DXT1:
+				size += int64((width+3)/4) * int64((height+3)/4) * 8
+			case Formats_TEX_DXT3, Formats_TEX_DXT5:
+				size += int64((width+3)/4) * int64((height+3)/4) * 16
+			default:
+				size += int64(width) * int64(height) * int64(bytesPerPixel(format))
+			}
+		}
+		return size
+	}
+	return 0
+}
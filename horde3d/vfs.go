@@ -0,0 +1,266 @@
+//Copyright (c) 2012 Tim Shannon
+//
+//Permission is hereby granted, free of charge, to any person obtaining a copy
+//of this software and associated documentation files (the "Software"), to deal
+//in the Software without restriction, including without limitation the rights
+//to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+//copies of the Software, and to permit persons to whom the Software is
+//furnished to do so, subject to the following conditions:
+//
+//The above copyright notice and this permission notice shall be included in
+//all copies or substantial portions of the Software.
+//
+//THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+//IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+//FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+//AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+//LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+//OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+//THE SOFTWARE.
+
+package horde3d
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ResourceFS is implemented by file systems that resolve resource names themselves.  The
+// resource loaders use OpenResource instead of opening the resource name directly.
+type ResourceFS interface {
+	fs.FS
+	OpenResource(resType int, name string) (fs.File, error)
+}
+
+// VFS is a read only file system layered from several mounted file systems, taking the place of
+// the search paths and resource paths of the Horde3D utility library.  A file is opened from the
+// mount with the highest priority that has it, so mod or patch directories can be mounted over
+// the base content.
+type VFS struct {
+	mu       sync.RWMutex
+	mounts   []vfsMount
+	prefixes map[int]string
+}
+
+type vfsMount struct {
+	name     string
+	fsys     fs.FS
+	priority int
+}
+
+// NewVFS creates an empty VFS
+func NewVFS() *VFS {
+	return &VFS{prefixes: make(map[int]string)}
+}
+
+// Mount adds fsys under name.  Mounts with a higher priority are searched first, and of mounts
+// with the same priority the one mounted last is searched first.
+func (v *VFS) Mount(name string, fsys fs.FS, priority int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	m := vfsMount{name: name, fsys: fsys, priority: priority}
+	i := slices.IndexFunc(v.mounts, func(o vfsMount) bool { return o.priority <= priority })
+	if i < 0 {
+		i = len(v.mounts)
+	}
+	v.mounts = slices.Insert(v.mounts, i, m)
+}
+
+// Unmount removes the mount added under name and reports whether it existed
+func (v *VFS) Unmount(name string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	i := slices.IndexFunc(v.mounts, func(m vfsMount) bool { return m.name == name })
+	if i < 0 {
+		return false
+	}
+	v.mounts = slices.Delete(v.mounts, i, i+1)
+	return true
+}
+
+// Mounts returns the mount names in search order
+func (v *VFS) Mounts() []string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	names := make([]string, len(v.mounts))
+	for i, m := range v.mounts {
+		names[i] = m.name
+	}
+	return names
+}
+
+// SetResourcePath sets the directory that resources of resType are looked up in, like
+// h3dutSetResourcePath
+func (v *VFS) SetResourcePath(resType int, dir string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	dir = strings.Trim(dir, "/")
+	if dir == "" || dir == "." {
+		delete(v.prefixes, resType)
+		return
+	}
+	v.prefixes[resType] = dir
+}
+
+// ResourcePath returns the directory set for resType
+func (v *VFS) ResourcePath(resType int) string {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return v.prefixes[resType]
+}
+
+// Open opens the named file from the highest priority mount that has it
+func (v *VFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	for _, m := range v.snapshot() {
+		f, err := m.fsys.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		// directories list the merged entries of all mounts
+		if info, err := f.Stat(); err == nil && info.IsDir() {
+			entries, err := v.ReadDir(name)
+			if err != nil {
+				f.Close()
+				return nil, err
+			}
+			return &vfsDir{File: f, entries: entries}, nil
+		}
+		return f, nil
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir merges the directory entries of all mounts, with entries of higher priority mounts
+// hiding those of the same name in lower ones
+func (v *VFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	found := false
+	seen := make(map[string]bool)
+	var entries []fs.DirEntry
+	for _, m := range v.snapshot() {
+		list, err := fs.ReadDir(m.fsys, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		found = true
+		for _, e := range list {
+			if !seen[e.Name()] {
+				seen[e.Name()] = true
+				entries = append(entries, e)
+			}
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	slices.SortFunc(entries, func(a, b fs.DirEntry) int { return strings.Compare(a.Name(), b.Name()) })
+	return entries, nil
+}
+
+// OpenResource opens the file of the named resource of resType, below the resource path set for
+// the type
+func (v *VFS) OpenResource(resType int, name string) (fs.File, error) {
+	return v.Open(v.resolve(resType, name))
+}
+
+// Locate returns the mount the named resource is opened from and its path within the mount
+func (v *VFS) Locate(resType int, name string) (mount string, file string, err error) {
+	file = v.resolve(resType, name)
+	for _, m := range v.snapshot() {
+		_, err := fs.Stat(m.fsys, file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return "", file, err
+		}
+		return m.name, file, nil
+	}
+	return "", file, &fs.PathError{Op: "locate", Path: file, Err: fs.ErrNotExist}
+}
+
+// Explain describes how the named resource is resolved, listing every mount in search order and
+// whether it has the file, for debugging content that is picked up from the wrong place
+func (v *VFS) Explain(resType int, name string) string {
+	file := v.resolve(resType, name)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %q -> %q\n", resTypeName(resType), name, file)
+	used := false
+	for _, m := range v.snapshot() {
+		_, err := fs.Stat(m.fsys, file)
+		var state string
+		switch {
+		case err == nil && !used:
+			state = "found, used"
+			used = true
+		case err == nil:
+			state = "found, hidden"
+		case errors.Is(err, fs.ErrNotExist):
+			state = "not found"
+		default:
+			state = err.Error()
+		}
+		fmt.Fprintf(&b, "  %s (priority %d): %s\n", m.name, m.priority, state)
+	}
+	if !used {
+		b.WriteString("  not found in any mount\n")
+	}
+	return b.String()
+}
+
+type vfsDir struct {
+	fs.File
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *vfsDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rest))
+	d.offset += n
+	return rest[:n], nil
+}
+
+func (v *VFS) resolve(resType int, name string) string {
+	v.mu.RLock()
+	prefix := v.prefixes[resType]
+	v.mu.RUnlock()
+
+	return path.Join(prefix, resourcePath(name))
+}
+
+func (v *VFS) snapshot() []vfsMount {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
+	return slices.Clone(v.mounts)
+}